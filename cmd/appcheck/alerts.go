@@ -0,0 +1,43 @@
+package main
+
+import (
+	"fmt"
+	"log"
+
+	"myproject/internal/alert"
+	"myproject/internal/metrics"
+	"myproject/internal/storage"
+	"myproject/internal/watchlist"
+)
+
+// raiseAlerts checks a run's records against the watchlist's alert rules,
+// compared with each app's previous rank in the history database, and
+// reports and saves the alerts that get past cooldowns and de-duplication.
+// Problems are logged: alerting never fails a run.
+func raiseAlerts(sess *session, wl *watchlist.Watchlist, records []storage.Record) []alert.Alert {
+	if sess == nil || len(wl.Alerts) == 0 || len(records) == 0 {
+		return nil
+	}
+
+	prev, err := sess.hist.PreviousRanks(records[0].Time)
+	if err != nil {
+		log.Printf("Error checking alerts: %v", err)
+		return nil
+	}
+
+	engine := alert.New(wl)
+	alerts, err := engine.Filter(engine.Evaluate(prev, records), sess.hist.LastAlert)
+	if err != nil {
+		log.Printf("Error checking alerts: %v", err)
+		return nil
+	}
+
+	for _, a := range alerts {
+		fmt.Printf("Alert %s: %s\n", a.Rule, a.Message)
+		if err := sess.hist.SaveAlert(sess.runID, a); err != nil {
+			log.Printf("Error saving alert: %v", err)
+			metrics.StorageErrors.WithLabelValues(metrics.TargetHistory).Inc()
+		}
+	}
+	return alerts
+}
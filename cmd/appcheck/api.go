@@ -0,0 +1,65 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"myproject/internal/api"
+	"myproject/internal/history"
+	"myproject/internal/watchlist"
+)
+
+// runAPI serves the rank history and /metrics over HTTP until SIGINT or
+// SIGTERM, then lets requests in flight finish.
+func runAPI(args []string) error {
+	fs := flag.NewFlagSet("api", flag.ExitOnError)
+	watchlistPath := fs.String("watchlist", watchlist.DefaultPath, "watchlist file")
+	dbPath := fs.String("db", history.DefaultPath, "history database")
+	addr := fs.String("addr", "127.0.0.1:8080", "address to listen on")
+	fs.Parse(args)
+
+	wl, err := watchlist.Load(*watchlistPath)
+	if err != nil {
+		return err
+	}
+	hist, err := history.Open(*dbPath)
+	if err != nil {
+		return err
+	}
+	defer hist.Close()
+
+	srv := &http.Server{
+		Addr:              *addr,
+		Handler:           api.Handler(hist, wl),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	shutdown := make(chan struct{})
+	go func() {
+		defer close(shutdown)
+		<-ctx.Done()
+		stop()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+		if err := srv.Shutdown(shutdownCtx); err != nil {
+			log.Printf("Error shutting down: %v", err)
+		}
+	}()
+
+	log.Printf("Serving the API on http://%s", *addr)
+	if err := srv.ListenAndServe(); !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	// Keep the database open until the last request is done
+	<-shutdown
+	return nil
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"path/filepath"
+	"time"
+
+	"myproject/internal/appstore"
+	"myproject/internal/catalog"
+	"myproject/internal/chart"
+	"myproject/internal/history"
+	"myproject/internal/storage"
+)
+
+func runAppPage(args []string) error {
+	fs := flag.NewFlagSet("app-page", flag.ExitOnError)
+	url := fs.String("url", "https://apps.apple.com/us/app/coinbase-buy-bitcoin-ether/id886427730", "App Store product page")
+	out := fs.String("out", filepath.Join(storage.Dir, "appleappcoinbase.csv"), "file to append to")
+	dbPath := fs.String("db", history.DefaultPath, "history database")
+	fs.Parse(args)
+
+	sess, err := openSession(*dbPath, "app-page")
+	if err != nil {
+		return err
+	}
+	defer sess.close()
+
+	app, err := appstore.ScrapeAppPage(*url)
+	if err != nil {
+		return fmt.Errorf("fetch page: %w", err)
+	}
+	now := time.Now()
+	timestamp := now.Format("2006-01-02 15:04:05")
+
+	header := [][]string{{"Name", "Rank", "Timestamp"}}
+	if err := storage.AppendRow(*out, header, []string{app.Name, app.CategoryRank, timestamp}); err != nil {
+		return err
+	}
+	fmt.Printf("Appended to %s: %s, %s, %s\n", *out, app.Name, app.CategoryRank, timestamp)
+
+	// The badge only shows while the app is charting, so there's nothing to
+	// record in the history otherwise
+	rank, category, ok := app.Rank()
+	country, trackID, urlOK := appstore.ParsePageURL(*url)
+	if ok && urlOK {
+		sess.saveRanks(0, []storage.Record{{
+			Time:     now,
+			Store:    chart.StoreIOS,
+			Device:   chart.DeviceIPhone,
+			Country:  catalog.CountryCode(country),
+			Category: catalog.CategoryKey(category),
+			List:     appstore.BadgeList,
+			AppID:    trackID,
+			AppName:  app.Name,
+			Rank:     rank,
+			Status:   storage.StatusRanked,
+		}})
+	}
+
+	return nil
+}
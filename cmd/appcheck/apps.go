@@ -0,0 +1,56 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"myproject/internal/appstore"
+	"myproject/internal/catalog"
+	"myproject/internal/watchlist"
+)
+
+func runApps(args []string) error {
+	fs := flag.NewFlagSet("apps", flag.ExitOnError)
+	watchlistPath := fs.String("watchlist", watchlist.DefaultPath, "watchlist file")
+	country := fs.String("country", "US", "ISO code of the App Store storefront to look the apps up in")
+	fs.Parse(args)
+
+	storefront, ok := catalog.LookupCountry(*country)
+	if !ok {
+		return fmt.Errorf("unknown country %q", *country)
+	}
+
+	wl, err := watchlist.Load(*watchlistPath)
+	if err != nil {
+		return err
+	}
+
+	var ids []string
+	for _, app := range wl.Apps {
+		if app.IOSID != "" {
+			ids = append(ids, app.IOSID)
+		}
+	}
+
+	details, err := appstore.NewClient().Lookup(context.Background(), storefront.Storefront, ids...)
+	if err != nil {
+		return fmt.Errorf("lookup: %w", err)
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "App\tiOS ID\tName\tSeller\tPrice\tGenre\tRating\tRatings\tVersion\tReleased")
+	for _, app := range wl.Apps {
+		d, ok := details[app.IOSID]
+		if !ok {
+			fmt.Fprintf(w, "%s\t%s\t(not found)\n", app.Name, app.IOSID)
+			continue
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%.2f %s\t%s\t%.2f\t%d\t%s\t%s\n",
+			app.Name, app.IOSID, d.Name, d.Seller, d.Price, d.Currency, d.Genre,
+			d.Rating, d.RatingCount, d.Version, d.ReleaseDate.Format("2006-01-02"))
+	}
+	return w.Flush()
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"time"
+
+	"myproject/internal/chart"
+	"myproject/internal/history"
+	"myproject/internal/metrics"
+	"myproject/internal/stage"
+	"myproject/internal/storage"
+)
+
+func runChart(args []string) error {
+	fs := flag.NewFlagSet("chart", flag.ExitOnError)
+	countries := fs.String("countries", "US,GB", "comma-separated ISO country codes")
+	store := fs.String("store", chart.StoreIOS, "store to scrape: ios or play")
+	device := fs.String("device", chart.DeviceIPhone, "App Store device: iphone or ipad (ignored for play)")
+	category := fs.String("category", "finance", "chart category")
+	list := fs.String("list", chart.ListFree, "chart list: free, paid, grossing or new")
+	limit := fs.Int("limit", 100, "keep entries ranked at or above this position")
+	dbPath := fs.String("db", history.DefaultPath, "history database")
+	opts := addScrapeFlags(fs)
+	fs.Parse(args)
+
+	if *store != chart.StoreIOS {
+		*device = ""
+	}
+	var reqs []chart.Request
+	for _, country := range splitList(*countries) {
+		reqs = append(reqs, chart.Request{Store: *store, Device: *device, Country: country, Category: *category, List: *list})
+	}
+	reqs, err := checkRequests(*opts, reqs)
+	if err != nil {
+		return err
+	}
+
+	sess, err := openSession(*dbPath, "chart")
+	if err != nil {
+		return err
+	}
+	defer sess.close()
+
+	s, err := newScraper(*opts, sess)
+	if err != nil {
+		return err
+	}
+	defer s.close()
+
+	results := s.scrapeAll(reqs)
+	for i, res := range results {
+		if res.err != nil {
+			log.Printf("Error scraping %s: %v", res.req, res.err)
+			continue
+		}
+		apps := res.chart.Top(*limit)
+
+		filename := storage.ChartPath(res.req, time.Now())
+		if err := storage.WriteChart(filename, apps); err != nil {
+			// Keep going so one unwritable file doesn't lose the other charts
+			log.Printf("Error saving %s: %v", res.req, err)
+			metrics.StorageErrors.WithLabelValues(metrics.TargetCSV).Inc()
+			results[i].err = stage.Wrap(stage.Storage, err)
+			continue
+		}
+		fmt.Printf("Scraped %d apps for %s and saved to %s\n", len(apps), res.req, filename)
+	}
+
+	report := newRunReport(results)
+	report.print()
+	return report.err()
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	"myproject/internal/digest"
+	"myproject/internal/history"
+	"myproject/internal/notify"
+	"myproject/internal/watchlist"
+)
+
+func runDigest(args []string) error {
+	fs := flag.NewFlagSet("digest", flag.ExitOnError)
+	watchlistPath := fs.String("watchlist", watchlist.DefaultPath, "watchlist file")
+	dbPath := fs.String("db", history.DefaultPath, "history database")
+	day := fs.String("day", "", "day to summarise as YYYY-MM-DD; yesterday by default")
+	dryRun := fs.Bool("dry-run", false, "print the plain-text digest instead of mailing it")
+	fs.Parse(args)
+
+	wl, err := watchlist.Load(*watchlistPath)
+	if err != nil {
+		return err
+	}
+
+	t := time.Now().AddDate(0, 0, -1)
+	if *day != "" {
+		if t, err = time.ParseInLocation("2006-01-02", *day, time.Local); err != nil {
+			return fmt.Errorf("-day: %w", err)
+		}
+	}
+
+	return sendDigest(wl, *dbPath, t, *dryRun)
+}
+
+// sendDigest builds the digest for day from the history database and mails
+// it to the watchlist's email recipients, or prints it when dryRun is set.
+func sendDigest(wl *watchlist.Watchlist, dbPath string, day time.Time, dryRun bool) error {
+	if wl.Notify.Email == nil && !dryRun {
+		return errors.New("the watchlist has no notify.email")
+	}
+
+	hist, err := history.Open(dbPath)
+	if err != nil {
+		return err
+	}
+	defer hist.Close()
+
+	start := time.Date(day.Year(), day.Month(), day.Day(), 0, 0, 0, 0, time.Local)
+	records, err := hist.Ranks(start.AddDate(0, 0, -1), start.AddDate(0, 0, 1))
+	if err != nil {
+		return err
+	}
+	for i := range records {
+		records[i].Time = records[i].Time.Local()
+	}
+	d := digest.Build(start, records, wl.Apps, chartLabel)
+
+	text, err := d.Text()
+	if err != nil {
+		return err
+	}
+	if dryRun {
+		fmt.Print(text)
+		return nil
+	}
+	html, err := d.HTML()
+	if err != nil {
+		return err
+	}
+
+	cfg := *wl.Notify.Email
+	m := &notify.Mailer{Config: cfg}
+	if cfg.PasswordEnv != "" {
+		if m.Password = os.Getenv(cfg.PasswordEnv); m.Password == "" {
+			return fmt.Errorf("email: %s is not set", cfg.PasswordEnv)
+		}
+	}
+	if err := m.Mail(d.Subject(), text, html); err != nil {
+		return fmt.Errorf("mail digest: %w", err)
+	}
+	fmt.Printf("Mailed the digest for %s (%d rows) to %d recipients\n", start.Format("2006-01-02"), len(d.Rows), len(cfg.To))
+	return nil
+}
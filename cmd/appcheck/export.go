@@ -0,0 +1,38 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"path/filepath"
+
+	"myproject/internal/appfigures"
+	"myproject/internal/storage"
+)
+
+func runExport(args []string) error {
+	fs := flag.NewFlagSet("export", flag.ExitOnError)
+	in := fs.String("in", storage.RecordsPath, "rank records file")
+	out := fs.String("out", filepath.Join(storage.Dir, "apps_ranks_wide.csv"), "spreadsheet file to write")
+	fs.Parse(args)
+
+	records, err := storage.ReadRecords(*in)
+	if err != nil {
+		return err
+	}
+
+	if err := storage.WriteWide(*out, records, chartLabel); err != nil {
+		return err
+	}
+	fmt.Printf("Exported %d ranks to %s\n", len(records), *out)
+
+	return nil
+}
+
+// chartLabel is the heading a chart gets in exports and the digest, e.g.
+// "United States - iOS App Store (finance, free, iphone)".
+func chartLabel(k storage.ChartKey) string {
+	if k.Device != "" {
+		return fmt.Sprintf("%s (%s, %s, %s)", appfigures.ChartLabel(k.Country, k.Store), k.Category, k.List, k.Device)
+	}
+	return fmt.Sprintf("%s (%s, %s)", appfigures.ChartLabel(k.Country, k.Store), k.Category, k.List)
+}
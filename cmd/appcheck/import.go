@@ -0,0 +1,55 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"time"
+
+	"myproject/internal/history"
+	"myproject/internal/importer"
+	"myproject/internal/storage"
+	"myproject/internal/watchlist"
+)
+
+func runImport(args []string) error {
+	fs := flag.NewFlagSet("import", flag.ExitOnError)
+	dir := fs.String("dir", storage.Dir, "folder of legacy CSV files")
+	watchlistPath := fs.String("watchlist", watchlist.DefaultPath, "watchlist file, used to identify the apps in old files")
+	tz := fs.String("tz", "Local", "time zone the legacy timestamps were written in, e.g. Europe/London")
+	dbPath := fs.String("db", history.DefaultPath, "history database")
+	fs.Parse(args)
+
+	wl, err := watchlist.Load(*watchlistPath)
+	if err != nil {
+		return err
+	}
+	loc, err := time.LoadLocation(*tz)
+	if err != nil {
+		return err
+	}
+
+	sess, err := openSession(*dbPath, "import")
+	if err != nil {
+		return err
+	}
+	defer sess.close()
+
+	im := &importer.Importer{Store: sess.hist, RunID: sess.runID, Watchlist: wl, Location: loc}
+	results, err := im.ImportDir(*dir)
+	for _, res := range results {
+		switch {
+		case res.Err != nil:
+			fmt.Printf("%s: failed: %v\n", res.Path, res.Err)
+			continue
+		case res.Layout == "":
+			fmt.Printf("%s: unrecognised layout, skipped\n", res.Path)
+			continue
+		}
+		fmt.Printf("%s: %s layout, %d new charts, %d new ranks, %d skipped, %d bad rows\n",
+			res.Path, res.Layout, res.Charts, res.Ranks, res.Skipped, res.Bad)
+		for _, p := range res.Problems {
+			fmt.Printf("  %s\n", p)
+		}
+	}
+	return err
+}
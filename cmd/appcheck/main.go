@@ -0,0 +1,89 @@
+// Command appcheck tracks where our apps sit in the App Store and Google Play
+// top charts.
+//
+// Usage:
+//
+//	appcheck <command> [flags]
+//
+// Commands:
+//
+//	chart     save full top charts to timestamped CSV files
+//	rank      record tracked-app ranks in one store's watchlist charts
+//	multi     record tracked-app ranks in every watchlist chart
+//	export    write the rank records as the wide spreadsheet layout
+//	import    load the CSV files in results/ into the history database
+//	app-page  record an app's category rank from its App Store page
+//	apps      show App Store details for the tracked apps
+//	digest    mail a day's rank digest to the watchlist's email recipients
+//	serve     run the watchlist jobs on their schedules until stopped
+//	api       serve the tracked apps, rank history and metrics over HTTP
+//
+// Run "appcheck <command> -h" for the flags of each command.
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"strings"
+)
+
+type command struct {
+	name    string
+	summary string
+	run     func(args []string) error
+}
+
+var commands = []command{
+	{"chart", "save full top charts to timestamped CSV files", runChart},
+	{"rank", "record tracked-app ranks in one store's watchlist charts", runRank},
+	{"multi", "record tracked-app ranks in every watchlist chart", runMulti},
+	{"export", "write the rank records as the wide spreadsheet layout", runExport},
+	{"import", "load the CSV files in results/ into the history database", runImport},
+	{"app-page", "record an app's category rank from its App Store page", runAppPage},
+	{"apps", "show App Store details for the tracked apps", runApps},
+	{"digest", "mail a day's rank digest to the watchlist's email recipients", runDigest},
+	{"serve", "run the watchlist jobs on their schedules until stopped", runServe},
+	{"api", "serve the tracked apps, rank history and metrics over HTTP", runAPI},
+}
+
+func main() {
+	if len(os.Args) < 2 {
+		usage()
+		os.Exit(2)
+	}
+
+	name, args := os.Args[1], os.Args[2:]
+	for _, cmd := range commands {
+		if cmd.name == name {
+			if err := cmd.run(args); err != nil {
+				log.Fatalf("%s: %v", name, err)
+			}
+			return
+		}
+	}
+
+	fmt.Fprintf(os.Stderr, "appcheck: unknown command %q\n\n", name)
+	usage()
+	os.Exit(2)
+}
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "Usage: appcheck <command> [flags]")
+	fmt.Fprintln(os.Stderr)
+	fmt.Fprintln(os.Stderr, "Commands:")
+	for _, cmd := range commands {
+		fmt.Fprintf(os.Stderr, "  %-9s %s\n", cmd.name, cmd.summary)
+	}
+}
+
+// splitList turns a comma-separated flag value into its non-empty parts.
+func splitList(s string) []string {
+	var parts []string
+	for _, p := range strings.Split(s, ",") {
+		if p = strings.TrimSpace(p); p != "" {
+			parts = append(parts, p)
+		}
+	}
+	return parts
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net"
+	"net/http"
+	"time"
+
+	"myproject/internal/history"
+	"myproject/internal/metrics"
+	"myproject/internal/metrics/historymetrics"
+)
+
+// serveMetrics serves /metrics on addr in the background, with the latest
+// ranks read from the history database at dbPath. The returned function
+// stops the server and closes the database.
+func serveMetrics(addr, dbPath string) (func(), error) {
+	hist, err := history.Open(dbPath)
+	if err != nil {
+		return nil, err
+	}
+	// Listen up front so a taken port fails the command
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		hist.Close()
+		return nil, err
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle("GET /metrics", metrics.Handler(historymetrics.Collector{Hist: hist}))
+	srv := &http.Server{Handler: mux, ReadHeaderTimeout: 10 * time.Second}
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		log.Printf("Serving metrics on http://%s/metrics", ln.Addr())
+		if err := srv.Serve(ln); !errors.Is(err, http.ErrServerClosed) {
+			log.Printf("Error serving metrics: %v", err)
+		}
+	}()
+
+	return func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := srv.Shutdown(ctx); err != nil {
+			log.Printf("Error shutting down metrics: %v", err)
+		}
+		<-done
+		hist.Close()
+	}, nil
+}
@@ -0,0 +1,25 @@
+package main
+
+import (
+	"flag"
+
+	"myproject/internal/history"
+	"myproject/internal/storage"
+	"myproject/internal/watchlist"
+)
+
+func runMulti(args []string) error {
+	fs := flag.NewFlagSet("multi", flag.ExitOnError)
+	watchlistPath := fs.String("watchlist", watchlist.DefaultPath, "watchlist file")
+	out := fs.String("out", storage.RecordsPath, "rank records file to append to")
+	dbPath := fs.String("db", history.DefaultPath, "history database")
+	opts := addScrapeFlags(fs)
+	fs.Parse(args)
+
+	wl, err := watchlist.Load(*watchlistPath)
+	if err != nil {
+		return err
+	}
+
+	return track("multi", wl.Requests(), wl, *opts, *dbPath, *out)
+}
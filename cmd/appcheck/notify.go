@@ -0,0 +1,61 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	"myproject/internal/alert"
+	"myproject/internal/fetch"
+	"myproject/internal/notify"
+	"myproject/internal/watchlist"
+)
+
+// newDispatcher builds the notification sinks the watchlist declares, or
+// returns nil when it declares none.
+func newDispatcher(wl *watchlist.Watchlist) (*notify.Dispatcher, error) {
+	var sinks []notify.Sink
+	for _, h := range wl.Notify.Webhooks {
+		wh := &notify.Webhook{Config: h}
+		if h.SecretEnv != "" {
+			secret := os.Getenv(h.SecretEnv)
+			if secret == "" {
+				return nil, fmt.Errorf("webhook %s: %s is not set", h.Name, h.SecretEnv)
+			}
+			wh.Secret = []byte(secret)
+		}
+		sinks = append(sinks, wh)
+	}
+	if len(sinks) == 0 {
+		return nil, nil
+	}
+
+	return &notify.Dispatcher{
+		Sinks:      sinks,
+		Backoff:    fetch.Backoff{Retries: 3, Initial: 2 * time.Second, Max: 30 * time.Second},
+		DeadLetter: notify.DeadLetterPath,
+	}, nil
+}
+
+// sendNotifications sends a run's alerts, if it raised any, and its summary.
+// Delivery failures are logged; the messages are in the dead-letter file.
+func sendNotifications(d *notify.Dispatcher, command string, report runReport, alerts []alert.Alert) {
+	if d == nil {
+		return
+	}
+
+	now := time.Now()
+	summary := report.summary()
+	messages := []notify.Message{{Event: watchlist.EventRuns, Command: command, At: now, Summary: &summary}}
+	if len(alerts) > 0 {
+		messages = append([]notify.Message{{Event: watchlist.EventAlerts, Command: command, At: now, Alerts: alerts}}, messages...)
+	}
+
+	for _, m := range messages {
+		if err := d.Send(context.Background(), m); err != nil {
+			log.Printf("Error sending %s notification: %v", m.Event, err)
+		}
+	}
+}
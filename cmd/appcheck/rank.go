@@ -0,0 +1,38 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+
+	"myproject/internal/chart"
+	"myproject/internal/history"
+	"myproject/internal/storage"
+	"myproject/internal/watchlist"
+)
+
+func runRank(args []string) error {
+	fs := flag.NewFlagSet("rank", flag.ExitOnError)
+	watchlistPath := fs.String("watchlist", watchlist.DefaultPath, "watchlist file")
+	store := fs.String("store", chart.StoreIOS, "store whose watchlist charts to scrape")
+	out := fs.String("out", storage.RecordsPath, "rank records file to append to")
+	dbPath := fs.String("db", history.DefaultPath, "history database")
+	opts := addScrapeFlags(fs)
+	fs.Parse(args)
+
+	wl, err := watchlist.Load(*watchlistPath)
+	if err != nil {
+		return err
+	}
+
+	var reqs []chart.Request
+	for _, req := range wl.Requests() {
+		if req.Store == *store {
+			reqs = append(reqs, req)
+		}
+	}
+	if len(reqs) == 0 {
+		return fmt.Errorf("the watchlist has no %s charts", *store)
+	}
+
+	return track("rank", reqs, wl, *opts, *dbPath, *out)
+}
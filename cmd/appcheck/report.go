@@ -0,0 +1,87 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"myproject/internal/notify"
+	"myproject/internal/stage"
+)
+
+// runReport sums up how each chart of a run went, so a failed chart is
+// reported rather than just missing from the output.
+type runReport struct {
+	succeeded []scrapeResult
+	retried   []scrapeResult // succeeded, but only after a retry
+	failed    []scrapeResult
+	saveErr   error // the run's ranks couldn't be written
+}
+
+func newRunReport(results []scrapeResult) runReport {
+	var r runReport
+	for _, res := range results {
+		switch {
+		case res.err != nil:
+			r.failed = append(r.failed, res)
+		case res.attempts > 1:
+			r.retried = append(r.retried, res)
+		default:
+			r.succeeded = append(r.succeeded, res)
+		}
+	}
+	return r
+}
+
+// print writes the summary and a line per retried or failed chart.
+func (r runReport) print() {
+	fmt.Printf("Charts: %d succeeded, %d succeeded after retrying, %d failed\n",
+		len(r.succeeded), len(r.retried), len(r.failed))
+	for _, res := range r.retried {
+		fmt.Printf("  retried %s: %d attempts\n", res.req, res.attempts)
+	}
+	for _, res := range r.failed {
+		fmt.Printf("  failed  %s after %d attempts: %v\n", res.req, res.attempts, res.err)
+	}
+	if r.saveErr != nil {
+		fmt.Printf("  ranks not saved: %v\n", r.saveErr)
+	}
+}
+
+// err returns an error naming the failed charts, or nil when none failed.
+// A failure to save the ranks is left to the caller, which has it already.
+func (r runReport) err() error {
+	if len(r.failed) == 0 {
+		return nil
+	}
+	names := make([]string, len(r.failed))
+	for i, res := range r.failed {
+		names[i] = fmt.Sprintf("%s (%s)", res.req, failedStage(res.err))
+	}
+	total := len(r.succeeded) + len(r.retried) + len(r.failed)
+	return fmt.Errorf("%d of %d charts failed: %s", len(r.failed), total, strings.Join(names, ", "))
+}
+
+// summary is the report in the form notifications carry.
+func (r runReport) summary() notify.Summary {
+	s := notify.Summary{Succeeded: []string{}, Retried: []string{}, Failed: []notify.Failure{}}
+	for _, res := range r.succeeded {
+		s.Succeeded = append(s.Succeeded, res.req.String())
+	}
+	for _, res := range r.retried {
+		s.Retried = append(s.Retried, res.req.String())
+	}
+	for _, res := range r.failed {
+		s.Failed = append(s.Failed, notify.Failure{Chart: res.req.String(), Stage: string(stage.Of(res.err)), Error: res.err.Error()})
+	}
+	if r.saveErr != nil {
+		s.Unsaved = r.saveErr.Error()
+	}
+	return s
+}
+
+func failedStage(err error) string {
+	if s := stage.Of(err); s != "" {
+		return string(s)
+	}
+	return "error"
+}
@@ -0,0 +1,236 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"myproject/internal/appfigures"
+	"myproject/internal/applerss"
+	"myproject/internal/catalog"
+	"myproject/internal/chart"
+	"myproject/internal/fetch"
+	"myproject/internal/match"
+	"myproject/internal/metrics"
+	"myproject/internal/replay"
+	"myproject/internal/stage"
+	"myproject/internal/watchlist"
+)
+
+// scrapeOptions are the fetch settings shared by every chart command.
+type scrapeOptions struct {
+	source     string // "appfigures" or "apple-rss"
+	browser    bool
+	depth      int
+	maxScrolls int
+	tabs       int
+	retries    int           // extra attempts for a chart that fails with a network error or timeout
+	retryWait  time.Duration // wait before the first retry; doubles after each one
+	record     string        // directory to save rendered pages to
+	replay     string        // directory of recorded pages to serve instead of the live site
+}
+
+// addScrapeFlags registers the fetch flags on fs.
+func addScrapeFlags(fs *flag.FlagSet) *scrapeOptions {
+	opts := &scrapeOptions{}
+	fs.StringVar(&opts.source, "source", "appfigures", "chart source: appfigures or apple-rss (iOS only)")
+	fs.BoolVar(&opts.browser, "browser", true, "render appfigures pages in headless Chrome instead of a plain HTTP fetch")
+	fs.IntVar(&opts.depth, "depth", 200, "keep scrolling until this many entries are loaded; 0 scrolls until no more load (browser only)")
+	fs.IntVar(&opts.maxScrolls, "max-scrolls", 30, "most scroll passes per chart (browser only)")
+	fs.IntVar(&opts.tabs, "tabs", 4, "charts to load at once in the shared browser")
+	fs.IntVar(&opts.retries, "retries", 2, "times to retry a chart that fails with a network error or timeout")
+	fs.DurationVar(&opts.retryWait, "retry-wait", 5*time.Second, "wait before the first retry; doubles after each one")
+	fs.StringVar(&opts.record, "record", "", "save each appfigures page and its request to this directory")
+	fs.StringVar(&opts.replay, "replay", "", "load appfigures pages from pages recorded in this directory instead of the live site")
+	return opts
+}
+
+// checkRequests normalises reqs against the catalogue and makes sure the
+// chosen source serves every one of them, so a bad combination fails before
+// the browser is started.
+func checkRequests(opts scrapeOptions, reqs []chart.Request) ([]chart.Request, error) {
+	checked := make([]chart.Request, 0, len(reqs))
+	for _, req := range reqs {
+		norm, err := catalog.Normalize(req)
+		if err != nil {
+			return nil, fmt.Errorf("chart %s: %w", req, err)
+		}
+		if opts.source == "apple-rss" {
+			if norm.Store != chart.StoreIOS {
+				return nil, fmt.Errorf("chart %s: apple-rss only has %s charts", norm, chart.StoreIOS)
+			}
+			if _, err := applerss.NewSource().FeedURL(norm); err != nil {
+				return nil, fmt.Errorf("chart %s: %w", norm, err)
+			}
+		}
+		checked = append(checked, norm)
+	}
+	return checked, nil
+}
+
+// scraper fetches charts for one command run. All appfigures pages share a
+// single browser.
+type scraper struct {
+	opts    scrapeOptions
+	loader  fetch.Loader
+	browser *fetch.Browser
+	replay  *replay.Server
+	sess    *session
+}
+
+func newScraper(opts scrapeOptions, sess *session) (*scraper, error) {
+	if opts.source == "apple-rss" && (opts.record != "" || opts.replay != "") {
+		return nil, fmt.Errorf("-record and -replay only apply to appfigures pages")
+	}
+
+	s := &scraper{opts: opts, loader: fetch.HTTPLoader{}, sess: sess}
+	switch {
+	case opts.replay != "":
+		srv, err := replay.NewServer(opts.replay)
+		if err != nil {
+			return nil, err
+		}
+		log.Printf("Replaying pages from %s at %s", opts.replay, srv.URL)
+		s.replay = srv
+		s.loader = srv.Loader()
+	case opts.browser && opts.source != "apple-rss":
+		renderOpts := fetch.DefaultRenderOptions(appfigures.LinkSelector)
+		renderOpts.Depth = opts.depth
+		renderOpts.MaxScrolls = opts.maxScrolls
+		browser, err := fetch.NewBrowser(opts.tabs, renderOpts)
+		if err != nil {
+			return nil, err
+		}
+		s.browser = browser
+		s.loader = browser
+	}
+
+	if opts.record != "" {
+		s.loader = &replay.Recorder{Loader: s.loader, Dir: opts.record}
+	}
+	return s, nil
+}
+
+// close shuts down the shared browser or replay server.
+func (s *scraper) close() {
+	if s.browser != nil {
+		s.browser.Close()
+	}
+	if s.replay != nil {
+		s.replay.Close()
+	}
+}
+
+// source returns the chart source for store.
+func (s *scraper) source(store string) (chart.Source, error) {
+	if s.opts.source == "apple-rss" {
+		if store != chart.StoreIOS {
+			return nil, fmt.Errorf("apple-rss only has %s charts", chart.StoreIOS)
+		}
+		return applerss.NewSource(), nil
+	}
+
+	switch store {
+	case chart.StoreIOS:
+		return appfigures.NewIOSSource(s.loader), nil
+	case chart.StorePlay:
+		return appfigures.NewPlaySource(s.loader), nil
+	}
+	return nil, fmt.Errorf("unknown store %q", store)
+}
+
+// scrapeResult is the outcome of one chart request.
+type scrapeResult struct {
+	req      chart.Request
+	chart    *chart.Chart // nil when err is set
+	chartID  int64        // history ID of the snapshot or failure
+	attempts int          // fetches made, retries included
+	err      error
+}
+
+// scrape fetches req, retrying network errors and timeouts with backoff, and
+// records the snapshot, or the failure, in the run's history.
+func (s *scraper) scrape(req chart.Request) scrapeResult {
+	res := scrapeResult{req: req}
+
+	source, err := s.source(req.Store)
+	if err != nil {
+		res.err = err
+		return res
+	}
+
+	backoff := fetch.Backoff{Retries: s.opts.retries, Initial: s.opts.retryWait, Max: time.Minute}
+	for {
+		res.attempts++
+		log.Printf("Scraping %s", req)
+		res.chart, res.err = source.Fetch(context.Background(), req)
+		if res.err == nil || !stage.Transient(res.err) || res.attempts > backoff.Retries {
+			break
+		}
+
+		metrics.Retries.WithLabelValues(req.Store).Inc()
+		wait := backoff.Delay(res.attempts)
+		log.Printf("Error scraping %s (attempt %d of %d), retrying in %s: %v",
+			req, res.attempts, backoff.Retries+1, wait.Round(time.Second), res.err)
+		time.Sleep(wait)
+	}
+	if res.err != nil {
+		metrics.ChartFailures.WithLabelValues(req.Store, failedStage(res.err)).Inc()
+		res.chartID = s.sess.saveChartError(source.Name(), req, res.attempts, res.err)
+		return res
+	}
+	log.Printf("Total apps found in %s: %d (loaded to #%d)", req, len(res.chart.Entries), res.chart.Depth)
+
+	res.chartID = s.sess.saveChart(res.chart, res.attempts)
+	return res
+}
+
+// scrapeAll fetches every request and returns the results in request order.
+// With a browser, charts load concurrently in its tabs; plain HTTP fetches
+// run one after another, with a pause in between unless they are replayed.
+func (s *scraper) scrapeAll(reqs []chart.Request) []scrapeResult {
+	results := make([]scrapeResult, len(reqs))
+
+	if s.browser == nil {
+		for i, req := range reqs {
+			if i > 0 && s.replay == nil {
+				fetch.RandomDelay()
+			}
+			results[i] = s.scrape(req)
+		}
+		return results
+	}
+
+	var wg sync.WaitGroup
+	for i, req := range reqs {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results[i] = s.scrape(req)
+		}()
+	}
+	wg.Wait()
+
+	return results
+}
+
+// trackedMatches matches the watchlist apps in c and logs the matches and any
+// ambiguous apps, which are left unmatched.
+func trackedMatches(c *chart.Chart, wl *watchlist.Watchlist) match.Result {
+	res := match.Chart(c.Request.Store, c.Entries, wl.Apps)
+	for _, m := range res.Matches {
+		log.Printf("Found %s at #%d by %s (%s)", m.App.Name, m.Entry.Rank, m.By, m.Entry.Name)
+	}
+	for _, amb := range res.Ambiguous {
+		var names []string
+		for _, e := range amb.Candidates {
+			names = append(names, fmt.Sprintf("#%d %s", e.Rank, e.Name))
+		}
+		log.Printf("Ambiguous match for %s in %s: %s", amb.App.Name, c.Request, strings.Join(names, "; "))
+	}
+	return res
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"myproject/internal/history"
+	"myproject/internal/schedule"
+	"myproject/internal/storage"
+	"myproject/internal/watchlist"
+)
+
+// runServe stays up and runs the watchlist's jobs on their schedules. A job
+// that fails is logged and runs again next time. On SIGINT or SIGTERM it stops
+// scheduling and exits once the running job, if any, has saved its results; a
+// second signal exits straight away. With -metrics-addr it also serves
+// Prometheus metrics on that address.
+func runServe(args []string) error {
+	fs := flag.NewFlagSet("serve", flag.ExitOnError)
+	watchlistPath := fs.String("watchlist", watchlist.DefaultPath, "watchlist file")
+	out := fs.String("out", storage.RecordsPath, "rank records file to append to")
+	dbPath := fs.String("db", history.DefaultPath, "history database")
+	metricsAddr := fs.String("metrics-addr", "", "address to serve /metrics on, e.g. 127.0.0.1:9090; off if empty")
+	opts := addScrapeFlags(fs)
+	fs.Parse(args)
+
+	wl, err := watchlist.Load(*watchlistPath)
+	if err != nil {
+		return err
+	}
+	if len(wl.Jobs) == 0 {
+		return errors.New("the watchlist has no jobs")
+	}
+
+	var jobs []schedule.Job
+	for _, job := range wl.Jobs {
+		sched, err := schedule.Parse(job.Cron, job.Every)
+		if err != nil {
+			return fmt.Errorf("job %s: %w", job.Name, err)
+		}
+		run := func() error {
+			// A digest job summarises the day before the one it runs on
+			return sendDigest(wl, *dbPath, time.Now().AddDate(0, 0, -1), false)
+		}
+		if job.Command == watchlist.JobTrack {
+			// Catch bad flags and charts now rather than at the first run
+			reqs, err := checkRequests(*opts, wl.JobRequests(job))
+			if err != nil {
+				return fmt.Errorf("job %s: %w", job.Name, err)
+			}
+			run = func() error {
+				return track("serve:"+job.Name, reqs, wl, *opts, *dbPath, *out)
+			}
+		}
+		jobs = append(jobs, schedule.Job{Name: job.Name, Schedule: sched, Jitter: job.Jitter, Run: run})
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	go func() {
+		<-ctx.Done()
+		stop()
+		log.Printf("Shutting down once any running job finishes")
+	}()
+
+	if *metricsAddr != "" {
+		closeMetrics, err := serveMetrics(*metricsAddr, *dbPath)
+		if err != nil {
+			return err
+		}
+		defer closeMetrics()
+	}
+
+	log.Printf("Serving %d jobs", len(jobs))
+	(&schedule.Scheduler{Jobs: jobs}).Run(ctx)
+	return nil
+}
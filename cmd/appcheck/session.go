@@ -0,0 +1,79 @@
+package main
+
+import (
+	"log"
+	"time"
+
+	"myproject/internal/chart"
+	"myproject/internal/history"
+	"myproject/internal/metrics"
+	"myproject/internal/storage"
+)
+
+// session ties one command invocation to a run in the history database.
+// History write failures are logged rather than aborting the scrape, so the
+// CSV output still gets written. A nil session records nothing.
+type session struct {
+	hist  *history.Store
+	runID int64
+}
+
+// openSession opens the history database and starts a run for command.
+func openSession(dbPath, command string) (*session, error) {
+	hist, err := history.Open(dbPath)
+	if err != nil {
+		return nil, err
+	}
+
+	runID, err := hist.StartRun(command, time.Now())
+	if err != nil {
+		hist.Close()
+		return nil, err
+	}
+	return &session{hist: hist, runID: runID}, nil
+}
+
+func (s *session) saveChart(c *chart.Chart, attempts int) int64 {
+	if s == nil {
+		return 0
+	}
+	id, err := s.hist.SaveChart(s.runID, c, attempts)
+	if err != nil {
+		log.Printf("Error saving %s to history: %v", c.Request, err)
+		metrics.StorageErrors.WithLabelValues(metrics.TargetHistory).Inc()
+	}
+	return id
+}
+
+func (s *session) saveChartError(source string, req chart.Request, attempts int, chartErr error) int64 {
+	if s == nil {
+		return 0
+	}
+	id, err := s.hist.SaveChartError(s.runID, source, req, time.Now(), attempts, chartErr)
+	if err != nil {
+		log.Printf("Error saving %s failure to history: %v", req, err)
+		metrics.StorageErrors.WithLabelValues(metrics.TargetHistory).Inc()
+	}
+	return id
+}
+
+func (s *session) saveRanks(chartID int64, records []storage.Record) {
+	if s == nil {
+		return
+	}
+	if err := s.hist.SaveRanks(s.runID, chartID, records); err != nil {
+		log.Printf("Error saving ranks to history: %v", err)
+		metrics.StorageErrors.WithLabelValues(metrics.TargetHistory).Inc()
+	}
+}
+
+// close marks the run finished and closes the database.
+func (s *session) close() {
+	if s == nil {
+		return
+	}
+	if err := s.hist.FinishRun(s.runID, time.Now()); err != nil {
+		log.Printf("Error finishing run: %v", err)
+	}
+	s.hist.Close()
+}
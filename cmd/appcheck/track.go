@@ -0,0 +1,118 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"myproject/internal/chart"
+	"myproject/internal/match"
+	"myproject/internal/metrics"
+	"myproject/internal/stage"
+	"myproject/internal/storage"
+	"myproject/internal/watchlist"
+)
+
+// track runs one tracking command: it scrapes reqs, saves the tracked ranks
+// to the history database, appends them to the records file at out, raises
+// any alerts the changes call for and sends the watchlist's notifications.
+func track(command string, reqs []chart.Request, wl *watchlist.Watchlist, opts scrapeOptions, dbPath, out string) error {
+	reqs, err := checkRequests(opts, reqs)
+	if err != nil {
+		return err
+	}
+	notifier, err := newDispatcher(wl)
+	if err != nil {
+		return err
+	}
+
+	sess, err := openSession(dbPath, command)
+	if err != nil {
+		return err
+	}
+	defer sess.close()
+
+	s, err := newScraper(opts, sess)
+	if err != nil {
+		return err
+	}
+	defer s.close()
+
+	// A failed write still raises alerts and notifies: the ranks are in the
+	// history database, and the failure belongs in the run's summary
+	records, report := trackCharts(reqs, wl, s)
+	if err := storage.AppendRecords(out, records); err != nil {
+		metrics.StorageErrors.WithLabelValues(metrics.TargetCSV).Inc()
+		report.saveErr = stage.Wrap(stage.Storage, fmt.Errorf("write %s: %w", out, err))
+	} else {
+		fmt.Printf("Saved %d ranks to %s\n", len(records), out)
+	}
+
+	alerts := raiseAlerts(sess, wl, records)
+	report.print()
+	sendNotifications(notifier, command, report, alerts)
+	return errors.Join(report.saveErr, report.err())
+}
+
+// trackCharts scrapes each chart and returns one record per watchlist app per
+// chart, plus how each chart went. A chart that fails to load still yields
+// records, marked as failed. Charts and records are also saved to the run's
+// history.
+func trackCharts(reqs []chart.Request, wl *watchlist.Watchlist, s *scraper) ([]storage.Record, runReport) {
+	now := time.Now()
+
+	results := s.scrapeAll(reqs)
+	var records []storage.Record
+	for _, res := range results {
+		if res.err != nil {
+			log.Printf("Error scraping %s: %v", res.req, res.err)
+		}
+		recs := chartRecords(now, res.req, res.chart, wl)
+		s.sess.saveRanks(res.chartID, recs)
+		records = append(records, recs...)
+	}
+	return records, newRunReport(results)
+}
+
+// chartRecords turns the tracked apps' positions in c into records. c is nil
+// when the chart couldn't be loaded.
+func chartRecords(now time.Time, req chart.Request, c *chart.Chart, wl *watchlist.Watchlist) []storage.Record {
+	var res match.Result
+	depth := 0
+	if c != nil {
+		res = trackedMatches(c, wl)
+		depth = c.Depth
+	}
+	ranks := res.Ranks()
+
+	records := make([]storage.Record, 0, len(wl.Apps))
+	for _, app := range wl.Apps {
+		r := storage.Record{
+			Time:     now,
+			Store:    req.Store,
+			Device:   req.Device,
+			Country:  req.Country,
+			Category: req.Category,
+			List:     req.List,
+			AppID:    match.RecordID(app, req.Store),
+			AppName:  app.Name,
+			Depth:    depth,
+		}
+
+		rank, ok := ranks[app.Key]
+		switch {
+		case c == nil:
+			r.Status = storage.StatusChartFailed
+		case ok:
+			r.Rank = rank
+			r.Status = storage.StatusRanked
+		case res.IsAmbiguous(app):
+			r.Status = storage.StatusNotMatched
+		default:
+			r.Status = storage.StatusBelowDepth
+		}
+		records = append(records, r)
+	}
+	return records
+}
@@ -0,0 +1,258 @@
+// Package alert compares each run's tracked ranks with the run before and
+// raises alerts for the changes the watchlist's rules care about.
+package alert
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"myproject/internal/chart"
+	"myproject/internal/match"
+	"myproject/internal/storage"
+	"myproject/internal/watchlist"
+)
+
+// Alert is one rule firing for one app in one chart.
+type Alert struct {
+	Rule    string        `json:"rule"`
+	Kind    string        `json:"kind"`
+	App     string        `json:"app"`
+	AppID   string        `json:"app_id"` // what the app's ranks are recorded under; see match.RecordID
+	Chart   chart.Request `json:"chart"`
+	From    int           `json:"from,omitempty"`  // rank in the previous run; 0 when it wasn't ranked
+	To      int           `json:"to,omitempty"`    // rank now; 0 when it isn't ranked
+	Other   string        `json:"other,omitempty"` // the competitor, for overtook alerts
+	Message string        `json:"message"`
+	At      time.Time     `json:"at"`
+}
+
+// Key identifies what the alert is about: the rule, app and chart. It uses the
+// app's ID, so renaming an app keeps its cooldowns. Cooldowns apply per key.
+func (a Alert) Key() string {
+	return strings.Join([]string{a.Rule, a.AppID, a.Chart.String()}, "|")
+}
+
+// Fingerprint identifies the change the alert reports, so the same change
+// isn't reported twice. The rule fixes the competitor of an overtook alert,
+// so Other is left out.
+func (a Alert) Fingerprint() string {
+	return strings.Join([]string{a.Key(), a.Kind, strconv.Itoa(a.From), strconv.Itoa(a.To)}, "|")
+}
+
+// Engine evaluates the watchlist's alert rules.
+type Engine struct {
+	rules []watchlist.AlertRule
+	apps  []watchlist.App
+}
+
+// New returns an engine for wl's rules and apps.
+func New(wl *watchlist.Watchlist) *Engine {
+	return &Engine{rules: wl.Alerts, apps: wl.Apps}
+}
+
+// position is where an app stood in a chart in one run.
+type position struct {
+	rec storage.Record
+	ok  bool // false when there is no usable record
+}
+
+func (p position) ranked() bool {
+	return p.ok && p.rec.Status == storage.StatusRanked
+}
+
+// index looks records up by chart and app ID. Records from charts that failed
+// say nothing about where an app stands, so they are left out.
+type index map[chart.Request]map[string]storage.Record
+
+func newIndex(records []storage.Record) index {
+	idx := make(index)
+	for _, r := range records {
+		if r.Status == storage.StatusChartFailed {
+			continue
+		}
+		req := requestOf(r)
+		if idx[req] == nil {
+			idx[req] = make(map[string]storage.Record)
+		}
+		idx[req][r.AppID] = r
+	}
+	return idx
+}
+
+func (idx index) at(req chart.Request, app watchlist.App) position {
+	r, ok := idx[req][match.RecordID(app, req.Store)]
+	return position{rec: r, ok: ok}
+}
+
+func requestOf(r storage.Record) chart.Request {
+	return chart.Request{Store: r.Store, Device: r.Device, Country: r.Country, Category: r.Category, List: r.List}
+}
+
+// Evaluate compares cur, one run's records, with prev, the latest earlier
+// record of each app in each chart, and returns the alerts raised in chart,
+// rule and app order.
+func (e *Engine) Evaluate(prev, cur []storage.Record) []Alert {
+	before, now := newIndex(prev), newIndex(cur)
+
+	var charts []chart.Request
+	seen := make(map[chart.Request]bool)
+	var at time.Time
+	for _, r := range cur {
+		if req := requestOf(r); !seen[req] {
+			seen[req] = true
+			charts = append(charts, req)
+		}
+		at = r.Time
+	}
+
+	var alerts []Alert
+	for _, req := range charts {
+		for _, rule := range e.rules {
+			for _, app := range e.apps {
+				if !rule.Watches(app) || (rule.Kind == watchlist.AlertOvertook && app.Key == rule.Competitor) {
+					continue
+				}
+				a, ok := e.check(rule, app, before, now, req)
+				if !ok {
+					continue
+				}
+				a.Rule, a.Kind, a.App, a.AppID, a.Chart, a.At = rule.Name, rule.Kind, app.Name, match.RecordID(app, req.Store), req, at
+				alerts = append(alerts, a)
+			}
+		}
+	}
+	return alerts
+}
+
+// check applies one rule to one app in one chart. The returned alert only has
+// its rank fields and message set.
+func (e *Engine) check(rule watchlist.AlertRule, app watchlist.App, before, now index, req chart.Request) (Alert, bool) {
+	was, is := before.at(req, app), now.at(req, app)
+	if !was.ok || !is.ok {
+		return Alert{}, false
+	}
+	from, to := was.rec.Rank, is.rec.Rank
+
+	switch rule.Kind {
+	case watchlist.AlertMoved:
+		if !was.ranked() || !is.ranked() || abs(to-from) < rule.Places {
+			return Alert{}, false
+		}
+		dir := "up"
+		if to > from {
+			dir = "down"
+		}
+		msg := fmt.Sprintf("%s moved %s %d places from #%d to #%d in %s", app.Name, dir, abs(to-from), from, to, req)
+		return Alert{From: from, To: to, Message: msg}, true
+
+	case watchlist.AlertTop:
+		wasIn := was.ranked() && from <= rule.Top
+		isIn := is.ranked() && to <= rule.Top
+		switch {
+		case !wasIn && isIn:
+			msg := fmt.Sprintf("%s entered the top %d in %s at #%d", app.Name, rule.Top, req, to)
+			return Alert{From: from, To: to, Message: msg}, true
+		case wasIn && !isIn:
+			msg := fmt.Sprintf("%s left the top %d in %s, from #%d to %s", app.Name, rule.Top, req, from, describe(is.rec))
+			return Alert{From: from, To: to, Message: msg}, true
+		}
+
+	case watchlist.AlertFellOff:
+		if was.ranked() && is.rec.Status == storage.StatusBelowDepth {
+			msg := fmt.Sprintf("%s fell off the loaded chart in %s, from #%d to %s", app.Name, req, from, describe(is.rec))
+			return Alert{From: from, Message: msg}, true
+		}
+
+	case watchlist.AlertOvertook:
+		competitor, ok := e.app(rule.Competitor)
+		if !ok {
+			return Alert{}, false
+		}
+		cWas, cIs := before.at(req, competitor), now.at(req, competitor)
+		if !cWas.ok || !cIs.ok || !above(was, cWas) || !above(cIs, is) {
+			return Alert{}, false
+		}
+		msg := fmt.Sprintf("%s overtook %s in %s: %s is now at %s, %s at %s",
+			competitor.Name, app.Name, req, competitor.Name, describe(cIs.rec), app.Name, describe(is.rec))
+		return Alert{From: from, To: to, Other: competitor.Name, Message: msg}, true
+	}
+	return Alert{}, false
+}
+
+func (e *Engine) app(key string) (watchlist.App, bool) {
+	for _, app := range e.apps {
+		if app.Key == key {
+			return app, true
+		}
+	}
+	return watchlist.App{}, false
+}
+
+// above reports whether a is ranked above b. An unranked app is below every
+// ranked one.
+func above(a, b position) bool {
+	switch {
+	case !a.ranked():
+		return false
+	case !b.ranked():
+		return true
+	}
+	return a.rec.Rank < b.rec.Rank
+}
+
+// describe says where a record puts its app, e.g. "#45" or "below #200".
+func describe(r storage.Record) string {
+	switch {
+	case r.Status == storage.StatusRanked:
+		return "#" + strconv.Itoa(r.Rank)
+	case r.Status == storage.StatusBelowDepth && r.Depth > 0:
+		return "below #" + strconv.Itoa(r.Depth)
+	}
+	return r.Status
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
+
+// repeatWindow is how long the same change, by Fingerprint, isn't reported
+// again, even by a rule without a cooldown. Past it, an app repeating a move
+// is news again.
+const repeatWindow = 24 * time.Hour
+
+// Filter drops alerts the rule's cooldown still covers and alerts that
+// repeat, within repeatWindow, the last one fired for the same key, so
+// evaluating the same runs twice reports their changes once. last returns
+// the last alert fired for a key, and whether there was one.
+func (e *Engine) Filter(alerts []Alert, last func(key string) (Alert, bool, error)) ([]Alert, error) {
+	cooldowns := make(map[string]time.Duration, len(e.rules))
+	for _, rule := range e.rules {
+		cooldowns[rule.Name] = rule.Cooldown
+	}
+
+	var kept []Alert
+	fired := make(map[string]bool)
+	for _, a := range alerts {
+		if fired[a.Key()] {
+			continue
+		}
+		prev, ok, err := last(a.Key())
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			since := a.At.Sub(prev.At)
+			if since < cooldowns[a.Rule] || since < repeatWindow && prev.Fingerprint() == a.Fingerprint() {
+				continue
+			}
+		}
+		fired[a.Key()] = true
+		kept = append(kept, a)
+	}
+	return kept, nil
+}
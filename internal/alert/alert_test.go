@@ -0,0 +1,172 @@
+package alert
+
+import (
+	"testing"
+	"time"
+
+	"myproject/internal/chart"
+	"myproject/internal/storage"
+	"myproject/internal/watchlist"
+)
+
+var (
+	usFree = chart.Request{Store: chart.StoreIOS, Device: chart.DeviceIPhone, Country: "US", Category: "finance", List: "free"}
+
+	coinbase = watchlist.App{Name: "Coinbase", Key: "Coinbase", IOSID: "886427730"}
+	okx      = watchlist.App{Name: "OKX", Key: "OKX", IOSID: "1327268470"}
+	// No store ID, so its ranks are recorded under its key
+	trust = watchlist.App{Name: "Trust Wallet", Key: "TrustWallet"}
+
+	run1 = time.Date(2024, 11, 6, 12, 0, 0, 0, time.UTC)
+	run2 = run1.Add(time.Hour)
+)
+
+// rec records app at rank in usFree, or below the loaded depth for rank 0.
+func rec(at time.Time, id, name string, rank int) storage.Record {
+	r := storage.Record{
+		Time: at, Store: usFree.Store, Device: usFree.Device, Country: usFree.Country,
+		Category: usFree.Category, List: usFree.List, AppID: id, AppName: name,
+		Rank: rank, Status: storage.StatusRanked,
+	}
+	if rank == 0 {
+		r.Status, r.Depth = storage.StatusBelowDepth, 200
+	}
+	return r
+}
+
+func TestEvaluate(t *testing.T) {
+	tests := []struct {
+		name      string
+		rule      watchlist.AlertRule
+		prev, cur []storage.Record
+		want      []string // messages
+	}{
+		{
+			name: "moved",
+			rule: watchlist.AlertRule{Name: "big-move", Kind: watchlist.AlertMoved, Places: 10},
+			prev: []storage.Record{rec(run1, "886427730", "Coinbase", 32), rec(run1, "TrustWallet", "Trust Wallet", 50)},
+			cur:  []storage.Record{rec(run2, "886427730", "Coinbase", 12), rec(run2, "TrustWallet", "Trust Wallet", 55)},
+			want: []string{"Coinbase moved up 20 places from #32 to #12 in ios/US/finance/free/iphone"},
+		},
+		{
+			name: "renamed app keeps its history",
+			rule: watchlist.AlertRule{Name: "big-move", Kind: watchlist.AlertMoved, Places: 10},
+			prev: []storage.Record{rec(run1, "886427730", "Coinbase: Buy Bitcoin", 32)},
+			cur:  []storage.Record{rec(run2, "886427730", "Coinbase", 12)},
+			want: []string{"Coinbase moved up 20 places from #32 to #12 in ios/US/finance/free/iphone"},
+		},
+		{
+			name: "same name, different app",
+			rule: watchlist.AlertRule{Name: "big-move", Kind: watchlist.AlertMoved, Places: 10},
+			prev: []storage.Record{rec(run1, "999", "Coinbase", 90)},
+			cur:  []storage.Record{rec(run2, "886427730", "Coinbase", 12)},
+		},
+		{
+			name: "overtaken",
+			rule: watchlist.AlertRule{Name: "okx", Kind: watchlist.AlertOvertook, Apps: []string{"Coinbase"}, Competitor: "OKX"},
+			prev: []storage.Record{rec(run1, "886427730", "Coinbase", 30), rec(run1, "1327268470", "OKX", 34)},
+			cur:  []storage.Record{rec(run2, "886427730", "Coinbase", 35), rec(run2, "1327268470", "OKX", 33)},
+			want: []string{"OKX overtook Coinbase in ios/US/finance/free/iphone: OKX is now at #33, Coinbase at #35"},
+		},
+		{
+			name: "overtaken by an app coming from below the loaded chart",
+			rule: watchlist.AlertRule{Name: "okx", Kind: watchlist.AlertOvertook, Apps: []string{"Coinbase"}, Competitor: "OKX"},
+			prev: []storage.Record{rec(run1, "886427730", "Coinbase", 30), rec(run1, "1327268470", "OKX", 0)},
+			cur:  []storage.Record{rec(run2, "886427730", "Coinbase", 0), rec(run2, "1327268470", "OKX", 33)},
+			want: []string{"OKX overtook Coinbase in ios/US/finance/free/iphone: OKX is now at #33, Coinbase at below #200"},
+		},
+		{
+			name: "competitor already ahead",
+			rule: watchlist.AlertRule{Name: "okx", Kind: watchlist.AlertOvertook, Apps: []string{"Coinbase"}, Competitor: "OKX"},
+			prev: []storage.Record{rec(run1, "886427730", "Coinbase", 40), rec(run1, "1327268470", "OKX", 34)},
+			cur:  []storage.Record{rec(run2, "886427730", "Coinbase", 35), rec(run2, "1327268470", "OKX", 33)},
+		},
+		{
+			name: "entered the top 10",
+			rule: watchlist.AlertRule{Name: "top", Kind: watchlist.AlertTop, Top: 10},
+			prev: []storage.Record{rec(run1, "886427730", "Coinbase", 14), rec(run1, "1327268470", "OKX", 0)},
+			cur:  []storage.Record{rec(run2, "886427730", "Coinbase", 8), rec(run2, "1327268470", "OKX", 11)},
+			want: []string{"Coinbase entered the top 10 in ios/US/finance/free/iphone at #8"},
+		},
+		{
+			name: "left the top 10",
+			rule: watchlist.AlertRule{Name: "top", Kind: watchlist.AlertTop, Top: 10},
+			prev: []storage.Record{rec(run1, "886427730", "Coinbase", 10), rec(run1, "1327268470", "OKX", 3)},
+			cur:  []storage.Record{rec(run2, "886427730", "Coinbase", 0), rec(run2, "1327268470", "OKX", 1)},
+			want: []string{"Coinbase left the top 10 in ios/US/finance/free/iphone, from #10 to below #200"},
+		},
+		{
+			name: "fell off",
+			rule: watchlist.AlertRule{Name: "fell-off", Kind: watchlist.AlertFellOff},
+			prev: []storage.Record{rec(run1, "886427730", "Coinbase", 187), rec(run1, "TrustWallet", "Trust Wallet", 0)},
+			cur:  []storage.Record{rec(run2, "886427730", "Coinbase", 0), rec(run2, "TrustWallet", "Trust Wallet", 0)},
+			want: []string{"Coinbase fell off the loaded chart in ios/US/finance/free/iphone, from #187 to below #200"},
+		},
+		{
+			name: "failed chart says nothing",
+			rule: watchlist.AlertRule{Name: "fell-off", Kind: watchlist.AlertFellOff},
+			prev: []storage.Record{rec(run1, "886427730", "Coinbase", 30)},
+			cur: []storage.Record{{Time: run2, Store: usFree.Store, Device: usFree.Device, Country: usFree.Country,
+				Category: usFree.Category, List: usFree.List, AppID: "886427730", AppName: "Coinbase", Status: storage.StatusChartFailed}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := New(&watchlist.Watchlist{Apps: []watchlist.App{coinbase, okx, trust}, Alerts: []watchlist.AlertRule{tt.rule}})
+			alerts := e.Evaluate(tt.prev, tt.cur)
+			if len(alerts) != len(tt.want) {
+				t.Fatalf("got %d alerts, want %d: %+v", len(alerts), len(tt.want), alerts)
+			}
+			for i, a := range alerts {
+				if a.Message != tt.want[i] {
+					t.Errorf("alert %d = %q, want %q", i, a.Message, tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestFilter(t *testing.T) {
+	rule := watchlist.AlertRule{Name: "big-move", Kind: watchlist.AlertMoved, Places: 10, Cooldown: 6 * time.Hour}
+	e := New(&watchlist.Watchlist{Apps: []watchlist.App{coinbase}, Alerts: []watchlist.AlertRule{rule}})
+	alert := func(at time.Time, from, to int) Alert {
+		return Alert{Rule: rule.Name, Kind: rule.Kind, App: "Coinbase", AppID: "886427730", Chart: usFree, From: from, To: to, At: at}
+	}
+	fired := alert(run1, 32, 12)
+
+	tests := []struct {
+		name string
+		last *Alert // nil when nothing fired before
+		in   []Alert
+		want int
+	}{
+		{"first alert", nil, []Alert{alert(run2, 32, 12)}, 1},
+		{"within cooldown", &fired, []Alert{alert(run1.Add(5*time.Hour), 12, 40)}, 0},
+		{"cooldown expired", &fired, []Alert{alert(run1.Add(6*time.Hour), 12, 40)}, 1},
+		{"repeated change after cooldown", &fired, []Alert{alert(run1.Add(12*time.Hour), 32, 12)}, 0},
+		{"repeated change a day later", &fired, []Alert{alert(run1.Add(24*time.Hour), 32, 12)}, 1},
+		{"same key twice in one run", nil, []Alert{alert(run2, 32, 12), alert(run2, 32, 10)}, 1},
+		{"renamed app still cools down", &fired, []Alert{func() Alert {
+			a := alert(run1.Add(time.Hour), 12, 40)
+			a.App = "Coinbase: Buy Bitcoin"
+			return a
+		}()}, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			last := func(key string) (Alert, bool, error) {
+				if tt.last == nil || tt.last.Key() != key {
+					return Alert{}, false, nil
+				}
+				return *tt.last, true, nil
+			}
+			kept, err := e.Filter(tt.in, last)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(kept) != tt.want {
+				t.Errorf("kept %d alerts, want %d: %+v", len(kept), tt.want, kept)
+			}
+		})
+	}
+}
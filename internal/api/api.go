@@ -0,0 +1,359 @@
+// Package api serves the tracked apps and the rank history over HTTP, as
+// JSON or, when the client asks for it, CSV.
+//
+// Endpoints:
+//
+//	GET /apps                 tracked apps
+//	GET /apps/{key}/ranks     an app's ranks in one chart, raw or bucketed by hour or day
+//	GET /charts/latest        the latest full snapshot of one chart
+//	GET /runs                 runs, newest first, with how their charts went
+//	GET /runs/{id}            one run
+//	GET /metrics              Prometheus metrics, latest ranks and chart depths included
+//
+// Charts are picked with the store, country, category, list and device query
+// parameters. Lists take limit and offset; when there is more, the response
+// carries a Link header with rel="next" and, in JSON, next_offset.
+package api
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"myproject/internal/catalog"
+	"myproject/internal/chart"
+	"myproject/internal/history"
+	"myproject/internal/match"
+	"myproject/internal/metrics"
+	"myproject/internal/metrics/historymetrics"
+	"myproject/internal/watchlist"
+)
+
+// Page sizes.
+const (
+	DefaultLimit = 100
+	MaxLimit     = 1000
+)
+
+// DefaultRange is how far back rank series go unless from is given.
+const DefaultRange = 7 * 24 * time.Hour
+
+type server struct {
+	hist *history.Store
+	wl   *watchlist.Watchlist
+}
+
+// Handler returns the API over hist, with wl supplying the tracked apps.
+func Handler(hist *history.Store, wl *watchlist.Watchlist) http.Handler {
+	s := &server{hist: hist, wl: wl}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /apps", s.apps)
+	mux.HandleFunc("GET /apps/{key}/ranks", s.ranks)
+	mux.HandleFunc("GET /charts/latest", s.latestChart)
+	mux.HandleFunc("GET /runs", s.runs)
+	mux.HandleFunc("GET /runs/{id}", s.run)
+	mux.Handle("GET /metrics", metrics.Handler(historymetrics.Collector{Hist: hist}))
+	return mux
+}
+
+type appJSON struct {
+	Key         string `json:"key"`
+	Name        string `json:"name"`
+	IOSID       string `json:"ios_id,omitempty"`
+	PlayPackage string `json:"play_package,omitempty"`
+}
+
+func (s *server) apps(w http.ResponseWriter, r *http.Request) {
+	p, err := pageFrom(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	var apps []appJSON
+	for _, app := range s.wl.Apps[min(p.offset, len(s.wl.Apps)):] {
+		apps = append(apps, appJSON{Key: app.Key, Name: app.Name, IOSID: app.IOSID, PlayPackage: app.PlayPackage})
+	}
+	writeList(w, r, p, list[appJSON]{
+		items:  apps,
+		header: []string{"key", "name", "ios_id", "play_package"},
+		row:    func(a appJSON) []string { return []string{a.Key, a.Name, a.IOSID, a.PlayPackage} },
+	})
+}
+
+type pointJSON struct {
+	Time   time.Time `json:"time"`
+	Rank   int       `json:"rank,omitempty"`
+	Status string    `json:"status"`
+	Depth  int       `json:"depth,omitempty"`
+}
+
+type bucketJSON struct {
+	Start   time.Time `json:"start"`
+	Min     int       `json:"min,omitempty"`
+	Max     int       `json:"max,omitempty"`
+	Avg     float64   `json:"avg,omitempty"`
+	Ranked  int       `json:"ranked"`
+	Samples int       `json:"samples"`
+}
+
+func (s *server) ranks(w http.ResponseWriter, r *http.Request) {
+	var app *watchlist.App
+	for i := range s.wl.Apps {
+		if s.wl.Apps[i].Key == r.PathValue("key") {
+			app = &s.wl.Apps[i]
+		}
+	}
+	if app == nil {
+		writeError(w, http.StatusNotFound, errors.New("unknown app "+strconv.Quote(r.PathValue("key"))))
+		return
+	}
+
+	req, err := chartFrom(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	p, err := pageFrom(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	from, to, err := rangeFrom(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	// One extra row tells whether there is another page
+	q := history.SeriesQuery{App: match.RecordID(*app, req.Store), Chart: req, From: from, To: to, Limit: p.limit + 1, Offset: p.offset}
+
+	switch bucket := r.URL.Query().Get("bucket"); bucket {
+	case "", "raw":
+		records, err := s.hist.RankSeries(q)
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, err)
+			return
+		}
+		points := make([]pointJSON, len(records))
+		for i, rec := range records {
+			points[i] = pointJSON{Time: rec.Time, Rank: rec.Rank, Status: rec.Status, Depth: rec.Depth}
+		}
+		writeList(w, r, p, list[pointJSON]{
+			items:  points,
+			header: []string{"time", "rank", "status", "depth"},
+			row: func(pt pointJSON) []string {
+				return []string{pt.Time.Format(time.RFC3339), optionalInt(pt.Rank), pt.Status, optionalInt(pt.Depth)}
+			},
+		})
+
+	case history.BucketHour, history.BucketDay:
+		buckets, err := s.hist.RankBuckets(q, bucket)
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, err)
+			return
+		}
+		out := make([]bucketJSON, len(buckets))
+		for i, b := range buckets {
+			out[i] = bucketJSON{Start: b.Start, Min: b.Min, Max: b.Max, Avg: b.Avg, Ranked: b.Ranked, Samples: b.Samples}
+		}
+		writeList(w, r, p, list[bucketJSON]{
+			items:  out,
+			header: []string{"start", "min", "max", "avg", "ranked", "samples"},
+			row: func(b bucketJSON) []string {
+				avg := ""
+				if b.Ranked > 0 {
+					avg = strconv.FormatFloat(b.Avg, 'f', 2, 64)
+				}
+				return []string{b.Start.Format(time.RFC3339), optionalInt(b.Min), optionalInt(b.Max), avg,
+					strconv.Itoa(b.Ranked), strconv.Itoa(b.Samples)}
+			},
+		})
+
+	default:
+		writeError(w, http.StatusBadRequest, errors.New("bucket must be raw, hour or day"))
+	}
+}
+
+type chartJSON struct {
+	ID        int64         `json:"id"`
+	Request   chart.Request `json:"request"`
+	Source    string        `json:"source"`
+	FetchedAt time.Time     `json:"fetched_at"`
+	Depth     int           `json:"depth"`
+	Entries   int           `json:"entries"`
+}
+
+type entryJSON struct {
+	Rank      int    `json:"rank"`
+	AppID     string `json:"app_id,omitempty"`
+	Name      string `json:"name"`
+	Developer string `json:"developer,omitempty"`
+	Pricing   string `json:"pricing,omitempty"`
+	IAP       bool   `json:"iap"`
+	Icon      string `json:"icon,omitempty"`
+}
+
+func (s *server) latestChart(w http.ResponseWriter, r *http.Request) {
+	req, err := chartFrom(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	p, err := pageFrom(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	c, id, err := s.hist.LatestChart(req)
+	switch {
+	case errors.Is(err, history.ErrNotFound):
+		writeError(w, http.StatusNotFound, errors.New("no snapshot of "+req.String()))
+		return
+	case err != nil:
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	var entries []entryJSON
+	for _, e := range c.Entries[min(p.offset, len(c.Entries)):] {
+		entries = append(entries, entryJSON{Rank: e.Rank, AppID: e.AppID, Name: e.Name, Developer: e.Developer, Pricing: e.Pricing, IAP: e.IAP, Icon: e.Icon})
+	}
+	writeList(w, r, p, list[entryJSON]{
+		chart:  &chartJSON{ID: id, Request: c.Request, Source: c.Source, FetchedAt: c.FetchedAt, Depth: c.Depth, Entries: len(c.Entries)},
+		items:  entries,
+		header: []string{"rank", "app_id", "name", "developer", "pricing", "iap", "icon"},
+		row: func(e entryJSON) []string {
+			return []string{strconv.Itoa(e.Rank), e.AppID, e.Name, e.Developer, e.Pricing, strconv.FormatBool(e.IAP), e.Icon}
+		},
+	})
+}
+
+type runJSON struct {
+	ID         int64      `json:"id"`
+	Command    string     `json:"command"`
+	Status     string     `json:"status"`
+	StartedAt  time.Time  `json:"started_at"`
+	FinishedAt *time.Time `json:"finished_at,omitempty"`
+	Charts     int        `json:"charts"`
+	Failed     int        `json:"failed"`
+	Retried    int        `json:"retried"`
+	Alerts     int        `json:"alerts"`
+}
+
+func newRunJSON(run history.Run) runJSON {
+	out := runJSON{ID: run.ID, Command: run.Command, Status: run.Status(), StartedAt: run.StartedAt,
+		Charts: run.Charts, Failed: run.Failed, Retried: run.Retried, Alerts: run.Alerts}
+	if !run.FinishedAt.IsZero() {
+		out.FinishedAt = &run.FinishedAt
+	}
+	return out
+}
+
+var runHeader = []string{"id", "command", "status", "started_at", "finished_at", "charts", "failed", "retried", "alerts"}
+
+func runRow(run runJSON) []string {
+	finished := ""
+	if run.FinishedAt != nil {
+		finished = run.FinishedAt.Format(time.RFC3339)
+	}
+	return []string{strconv.FormatInt(run.ID, 10), run.Command, run.Status, run.StartedAt.Format(time.RFC3339), finished,
+		strconv.Itoa(run.Charts), strconv.Itoa(run.Failed), strconv.Itoa(run.Retried), strconv.Itoa(run.Alerts)}
+}
+
+func (s *server) runs(w http.ResponseWriter, r *http.Request) {
+	p, err := pageFrom(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	runs, err := s.hist.Runs(p.limit+1, p.offset)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	out := make([]runJSON, len(runs))
+	for i, run := range runs {
+		out[i] = newRunJSON(run)
+	}
+	writeList(w, r, p, list[runJSON]{items: out, header: runHeader, row: runRow})
+}
+
+func (s *server) run(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, errors.New("run id must be a number"))
+		return
+	}
+	run, err := s.hist.Run(id)
+	switch {
+	case errors.Is(err, history.ErrNotFound):
+		writeError(w, http.StatusNotFound, errors.New("no run "+r.PathValue("id")))
+		return
+	case err != nil:
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	writeOne(w, r, newRunJSON(run), runHeader, runRow)
+}
+
+// chartFrom reads the chart query parameters. category defaults to finance
+// and list to free, as in the chart command.
+func chartFrom(r *http.Request) (chart.Request, error) {
+	q := r.URL.Query()
+	req := chart.Request{
+		Store:    q.Get("store"),
+		Device:   q.Get("device"),
+		Country:  q.Get("country"),
+		Category: q.Get("category"),
+		List:     q.Get("list"),
+	}
+	if req.Category == "" {
+		req.Category = "finance"
+	}
+	if req.List == "" {
+		req.List = chart.ListFree
+	}
+	return catalog.Normalize(req)
+}
+
+// rangeFrom reads from and to as RFC 3339 times or dates. to defaults to now
+// and from to DefaultRange before to.
+func rangeFrom(r *http.Request) (time.Time, time.Time, error) {
+	to, err := timeParam(r, "to", time.Now())
+	if err != nil {
+		return time.Time{}, time.Time{}, err
+	}
+	from, err := timeParam(r, "from", to.Add(-DefaultRange))
+	if err != nil {
+		return time.Time{}, time.Time{}, err
+	}
+	if !from.Before(to) {
+		return time.Time{}, time.Time{}, errors.New("from must be before to")
+	}
+	return from, to, nil
+}
+
+func timeParam(r *http.Request, name string, def time.Time) (time.Time, error) {
+	v := r.URL.Query().Get(name)
+	if v == "" {
+		return def, nil
+	}
+	if t, err := time.Parse(time.RFC3339, v); err == nil {
+		return t, nil
+	}
+	if t, err := time.Parse(time.DateOnly, v); err == nil {
+		return t, nil
+	}
+	return time.Time{}, errors.New(name + " must be an RFC 3339 time or a YYYY-MM-DD date")
+}
+
+func optionalInt(v int) string {
+	if v == 0 {
+		return ""
+	}
+	return strconv.Itoa(v)
+}
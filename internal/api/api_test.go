@@ -0,0 +1,205 @@
+package api
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"myproject/internal/chart"
+	"myproject/internal/history"
+	"myproject/internal/storage"
+	"myproject/internal/watchlist"
+)
+
+var usFree = chart.Request{Store: chart.StoreIOS, Device: chart.DeviceIPhone, Country: "US", Category: "finance", List: "free"}
+
+// newTestServer fills a history database with one run: a US chart snapshot
+// and Coinbase's ranks over two days.
+func newTestServer(t *testing.T) *httptest.Server {
+	hist, err := history.Open(filepath.Join(t.TempDir(), "history.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { hist.Close() })
+
+	start := time.Date(2024, 10, 27, 10, 0, 0, 0, time.UTC)
+	runID, err := hist.StartRun("multi", start)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := &chart.Chart{Request: usFree, Source: "appfigures-ios", FetchedAt: start, Depth: 3, Entries: []chart.Entry{
+		{Rank: 1, AppID: "711923939", Name: "Cash App"},
+		{Rank: 2, AppID: "886427730", Name: "Coinbase: Buy Bitcoin & Ether"},
+		{Rank: 3, AppID: "1327268470", Name: "OKX: Buy Bitcoin BTC & Crypto"},
+	}}
+	chartID, err := hist.SaveChart(runID, c, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var records []storage.Record
+	for i, rank := range []int{12, 8, 0, 20} {
+		r := storage.Record{
+			Time: start.Add(time.Duration(i) * 12 * time.Hour), Store: usFree.Store, Device: usFree.Device, Country: usFree.Country,
+			Category: usFree.Category, List: usFree.List, AppID: "886427730", AppName: "Coinbase", Rank: rank, Status: storage.StatusRanked,
+		}
+		if i < 2 {
+			// Recorded before the watchlist caught up with a rename
+			r.AppName = "Coinbase: Buy Bitcoin & Ether"
+		}
+		if rank == 0 {
+			r.Status, r.Depth = storage.StatusBelowDepth, 200
+		}
+		records = append(records, r)
+	}
+	if err := hist.SaveRanks(runID, chartID, records); err != nil {
+		t.Fatal(err)
+	}
+	if err := hist.FinishRun(runID, start.Add(time.Minute)); err != nil {
+		t.Fatal(err)
+	}
+
+	wl := &watchlist.Watchlist{Apps: []watchlist.App{
+		{Name: "Coinbase", Key: "Coinbase", IOSID: "886427730"},
+		{Name: "OKX", Key: "OKX", IOSID: "1327268470"},
+	}}
+	srv := httptest.NewServer(Handler(hist, wl))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func get(t *testing.T, url, accept string, v any) *http.Response {
+	t.Helper()
+	req, _ := http.NewRequest("GET", url, nil)
+	if accept != "" {
+		req.Header.Set("Accept", accept)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if v != nil {
+		if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+			t.Fatalf("decode %s: %v", url, err)
+		}
+	}
+	return resp
+}
+
+func TestRanks(t *testing.T) {
+	srv := newTestServer(t)
+	base := srv.URL + "/apps/Coinbase/ranks?store=ios&country=us&from=2024-10-27&to=2024-10-30"
+
+	var raw struct {
+		Items      []pointJSON
+		NextOffset int `json:"next_offset"`
+	}
+	resp := get(t, base+"&limit=3", "", &raw)
+	if len(raw.Items) != 3 || raw.Items[0].Rank != 12 || raw.Items[2].Status != storage.StatusBelowDepth || raw.NextOffset != 3 {
+		t.Errorf("raw series = %+v", raw)
+	}
+	if link := resp.Header.Get("Link"); !strings.Contains(link, "offset=3") || !strings.Contains(link, `rel="next"`) {
+		t.Errorf("Link = %q", link)
+	}
+
+	var daily struct{ Items []bucketJSON }
+	get(t, base+"&bucket=day", "", &daily)
+	if len(daily.Items) != 2 {
+		t.Fatalf("got %d daily buckets, want 2: %+v", len(daily.Items), daily.Items)
+	}
+	if b := daily.Items[0]; b.Min != 8 || b.Max != 12 || b.Avg != 10 || b.Samples != 2 {
+		t.Errorf("first day = %+v", b)
+	}
+	if b := daily.Items[1]; b.Min != 20 || b.Ranked != 1 || b.Samples != 2 {
+		t.Errorf("second day = %+v", b)
+	}
+
+	resp = get(t, base+"&bucket=day", "text/csv, application/json;q=0.5", nil)
+	if ct := resp.Header.Get("Content-Type"); !strings.HasPrefix(ct, "text/csv") {
+		t.Errorf("Content-Type = %q, want CSV", ct)
+	}
+
+	if resp := get(t, srv.URL+"/apps/Nobody/ranks?store=ios&country=US", "", nil); resp.StatusCode != http.StatusNotFound {
+		t.Errorf("unknown app: status %d", resp.StatusCode)
+	}
+	if resp := get(t, srv.URL+"/apps/Coinbase/ranks?store=ios&country=XX", "", nil); resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("unknown country: status %d", resp.StatusCode)
+	}
+}
+
+func TestLatestChartCSV(t *testing.T) {
+	srv := newTestServer(t)
+
+	resp, err := http.Get(srv.URL + "/charts/latest?store=ios&country=US&format=csv&offset=1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	rows, err := csv.NewReader(resp.Body).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != 3 || rows[0][0] != "rank" || rows[1][2] != "Coinbase: Buy Bitcoin & Ether" {
+		t.Errorf("rows = %q", rows)
+	}
+
+	if resp := get(t, srv.URL+"/charts/latest?store=play&country=US", "", nil); resp.StatusCode != http.StatusNotFound {
+		t.Errorf("missing chart: status %d", resp.StatusCode)
+	}
+}
+
+func TestRuns(t *testing.T) {
+	srv := newTestServer(t)
+
+	var runs struct{ Items []runJSON }
+	get(t, srv.URL+"/runs", "", &runs)
+	if len(runs.Items) != 1 {
+		t.Fatalf("got %d runs, want 1", len(runs.Items))
+	}
+	if r := runs.Items[0]; r.Status != history.RunOK || r.Charts != 1 || r.Retried != 1 || r.FinishedAt == nil {
+		t.Errorf("run = %+v", r)
+	}
+
+	var run runJSON
+	get(t, srv.URL+"/runs/1", "", &run)
+	if run.ID != 1 || run.Command != "multi" {
+		t.Errorf("run = %+v", run)
+	}
+
+	var apps struct{ Items []appJSON }
+	get(t, srv.URL+"/apps", "", &apps)
+	if len(apps.Items) != 2 || apps.Items[1].Key != "OKX" {
+		t.Errorf("apps = %+v", apps.Items)
+	}
+}
+
+func TestMetrics(t *testing.T) {
+	srv := newTestServer(t)
+
+	resp, err := http.Get(srv.URL + "/metrics")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, want := range []string{
+		`appcheck_rank{app_id="886427730",category="finance",country="US",device="iphone",list="free",store="ios"} 20`,
+		`appcheck_chart_depth{category="finance",country="US",device="iphone",list="free",store="ios"} 3`,
+		`appcheck_history_collect_errors 0`,
+	} {
+		if !strings.Contains(string(body), want) {
+			t.Errorf("metrics missing %s", want)
+		}
+	}
+}
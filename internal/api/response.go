@@ -0,0 +1,146 @@
+package api
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"log"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// page is the limit and offset of a list request.
+type page struct {
+	limit, offset int
+}
+
+func pageFrom(r *http.Request) (page, error) {
+	p := page{limit: DefaultLimit}
+	q := r.URL.Query()
+	if v := q.Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 || n > MaxLimit {
+			return page{}, errors.New("limit must be between 1 and " + strconv.Itoa(MaxLimit))
+		}
+		p.limit = n
+	}
+	if v := q.Get("offset"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			return page{}, errors.New("offset must be a number of at least 0")
+		}
+		p.offset = n
+	}
+	return p, nil
+}
+
+// list is one page of a list response. items holds the page from the
+// offset on and may run past the limit: anything beyond it means there is a
+// next page.
+type list[T any] struct {
+	chart  *chartJSON // describes the list, for chart entries
+	items  []T
+	header []string
+	row    func(T) []string
+}
+
+// listJSON is the JSON form of a list.
+type listJSON[T any] struct {
+	Chart      *chartJSON `json:"chart,omitempty"`
+	Items      []T        `json:"items"`
+	NextOffset int        `json:"next_offset,omitempty"`
+}
+
+func writeList[T any](w http.ResponseWriter, r *http.Request, p page, l list[T]) {
+	items, next := l.items, 0
+	if len(items) > p.limit {
+		items, next = items[:p.limit], p.offset+p.limit
+		u := *r.URL
+		q := u.Query()
+		q.Set("offset", strconv.Itoa(next))
+		u.RawQuery = q.Encode()
+		w.Header().Set("Link", "<"+u.RequestURI()+`>; rel="next"`)
+	}
+
+	if !wantsCSV(r) {
+		if items == nil {
+			items = []T{}
+		}
+		writeJSON(w, listJSON[T]{Chart: l.chart, Items: items, NextOffset: next})
+		return
+	}
+
+	rows := make([][]string, 0, len(items)+1)
+	rows = append(rows, l.header)
+	for _, item := range items {
+		rows = append(rows, l.row(item))
+	}
+	writeCSV(w, rows)
+}
+
+// writeOne writes a single item as a JSON object or a one-row CSV.
+func writeOne[T any](w http.ResponseWriter, r *http.Request, item T, header []string, row func(T) []string) {
+	if wantsCSV(r) {
+		writeCSV(w, [][]string{header, row(item)})
+		return
+	}
+	writeJSON(w, item)
+}
+
+func writeJSON(w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		log.Printf("Error writing response: %v", err)
+	}
+}
+
+func writeCSV(w http.ResponseWriter, rows [][]string) {
+	w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+	if err := csv.NewWriter(w).WriteAll(rows); err != nil {
+		log.Printf("Error writing response: %v", err)
+	}
+}
+
+// writeError reports err as JSON whatever format was asked for. Server
+// errors are logged and not shown to the client.
+func writeError(w http.ResponseWriter, status int, err error) {
+	msg := err.Error()
+	if status >= 500 {
+		log.Printf("Error serving request: %v", err)
+		msg = http.StatusText(status)
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]string{"error": msg})
+}
+
+// wantsCSV reports whether the client asked for CSV, with format=csv or an
+// Accept header that prefers text/csv to JSON.
+func wantsCSV(r *http.Request) bool {
+	switch r.URL.Query().Get("format") {
+	case "csv":
+		return true
+	case "json":
+		return false
+	}
+
+	best, bestQ := "", 0.0
+	for _, part := range strings.Split(r.Header.Get("Accept"), ",") {
+		mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil || (mediaType != "text/csv" && mediaType != "application/json") {
+			continue
+		}
+		q := 1.0
+		if v, ok := params["q"]; ok {
+			if q, err = strconv.ParseFloat(v, 64); err != nil {
+				continue
+			}
+		}
+		if q > bestQ {
+			best, bestQ = mediaType, q
+		}
+	}
+	return best == "text/csv"
+}
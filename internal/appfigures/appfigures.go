@@ -0,0 +1,53 @@
+// Package appfigures builds appfigures.com top-chart URLs and parses the
+// rendered chart pages.
+package appfigures
+
+import (
+	"fmt"
+	"net/url"
+
+	"myproject/internal/catalog"
+	"myproject/internal/chart"
+)
+
+const baseURL = "https://appfigures.com/top-apps"
+
+var storeNames = map[string]string{
+	chart.StoreIOS:  "iOS App Store",
+	chart.StorePlay: "Google Play Store",
+}
+
+// ChartURL returns the appfigures page for req. iOS requests without a
+// device get the iPhone chart; the Play page shows the free chart unless
+// asked for another list.
+func ChartURL(req chart.Request) string {
+	country := req.Country
+	if c, ok := catalog.LookupCountry(country); ok {
+		country = c.Slug
+	}
+
+	if req.Store == chart.StoreIOS {
+		device := req.Device
+		if device == "" {
+			device = chart.DefaultDevice(req.Store)
+		}
+		return fmt.Sprintf("%s/ios-app-store/%s/%s/%s?list=%s",
+			baseURL, country, device, req.Category, url.QueryEscape(req.List))
+	}
+
+	u := fmt.Sprintf("%s/google-play/%s/%s", baseURL, country, req.Category)
+	if req.List != "" && req.List != chart.ListFree {
+		u += "?list=" + url.QueryEscape(req.List)
+	}
+	return u
+}
+
+// ChartLabel returns a spreadsheet heading such as
+// "United States - iOS App Store".
+func ChartLabel(country, store string) string {
+	name := country
+	if c, ok := catalog.LookupCountry(country); ok {
+		name = c.Name
+	}
+	return fmt.Sprintf("%s - %s", name, storeNames[store])
+}
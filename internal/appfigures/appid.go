@@ -0,0 +1,40 @@
+package appfigures
+
+import (
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+var (
+	// Play package names: at least two dot-separated identifiers
+	packageRegex = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9_]*(\.[A-Za-z0-9_]+)+$`)
+	// App Store track IDs appear as "id886427730", "-886427730" or "/886427730"
+	trackIDRegex = regexp.MustCompile(`(?:^|id|[-/_])(\d{6,})$`)
+)
+
+// AppIDFromHref extracts the store identity from a chart link: the App Store
+// track ID for iOS apps or the package name for Play apps. It returns "" when
+// the link carries neither.
+func AppIDFromHref(href string) string {
+	u, err := url.Parse(href)
+	if err != nil {
+		return ""
+	}
+
+	if id := u.Query().Get("id"); id != "" {
+		return strings.TrimPrefix(id, "id")
+	}
+
+	segments := strings.Split(strings.Trim(u.Path, "/"), "/")
+	for i := len(segments) - 1; i >= 0; i-- {
+		seg := segments[i]
+		if packageRegex.MatchString(seg) {
+			return seg
+		}
+		if m := trackIDRegex.FindStringSubmatch(seg); m != nil {
+			return m[1]
+		}
+	}
+	return ""
+}
@@ -0,0 +1,161 @@
+package appfigures
+
+import (
+	"encoding/json"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+
+	"myproject/internal/chart"
+)
+
+// stateAssignRegex finds inline state such as "window.__INITIAL_STATE__ = {".
+var stateAssignRegex = regexp.MustCompile(`(?:window\.)?__[A-Z_]+__\s*=\s*`)
+
+// Keys that hold each field in a chart row of the page state, in order of
+// preference. The state is the site's own and isn't documented, so rows are
+// recognised by shape rather than by path.
+var (
+	rankKeys      = []string{"rank", "position", "pos"}
+	nameKeys      = []string{"name", "title", "app_name"}
+	appIDKeys     = []string{"store_id", "track_id", "package_name", "package", "bundle_id", "app_id"}
+	urlKeys       = []string{"store_url", "url", "href", "link"}
+	developerKeys = []string{"developer", "developer_name", "publisher", "seller", "artist"}
+	priceKeys     = []string{"price", "pricing", "formatted_price", "price_label"}
+	currencyKeys  = []string{"currency", "price_currency"}
+	iconKeys      = []string{"icon", "icon_url", "icon_uri", "artwork", "image"}
+	iapKeys       = []string{"in_app_purchases", "has_in_app_purchases", "has_iap", "iap"}
+)
+
+// ParseState extracts chart entries from the hydration state a page embeds
+// in its script tags. It returns nil when the page has no state or none of it
+// looks like a chart.
+func ParseState(doc *goquery.Document) []chart.Entry {
+	var best []chart.Entry
+	doc.Find("script").Each(func(i int, s *goquery.Selection) {
+		state, ok := scriptState(s)
+		if !ok {
+			return
+		}
+		if entries := chartRows(state); len(entries) > len(best) {
+			best = entries
+		}
+	})
+	return best
+}
+
+// scriptState decodes the JSON in a script tag: either the whole body of a
+// JSON script or the value of an inline state assignment.
+func scriptState(s *goquery.Selection) (any, bool) {
+	text := s.Text()
+	if !strings.Contains(s.AttrOr("type", ""), "json") {
+		loc := stateAssignRegex.FindStringIndex(text)
+		if loc == nil {
+			return nil, false
+		}
+		text = text[loc[1]:]
+	}
+
+	// The decoder stops after the first value, ignoring a trailing ";"
+	dec := json.NewDecoder(strings.NewReader(text))
+	dec.UseNumber()
+	var state any
+	if err := dec.Decode(&state); err != nil {
+		return nil, false
+	}
+	return state, true
+}
+
+// chartRows walks the state and returns the entries of the array with the
+// most rows that carry a rank and a name.
+func chartRows(v any) []chart.Entry {
+	var best []chart.Entry
+	switch v := v.(type) {
+	case map[string]any:
+		for _, child := range v {
+			if entries := chartRows(child); len(entries) > len(best) {
+				best = entries
+			}
+		}
+	case []any:
+		var entries []chart.Entry
+		for _, child := range v {
+			if row, ok := child.(map[string]any); ok {
+				if e, ok := stateEntry(row); ok {
+					entries = append(entries, e)
+				}
+			}
+			if nested := chartRows(child); len(nested) > len(best) {
+				best = nested
+			}
+		}
+		if len(entries) > len(best) {
+			best = entries
+		}
+	}
+	return best
+}
+
+func stateEntry(row map[string]any) (chart.Entry, bool) {
+	rank, err := strconv.Atoi(stateString(row, rankKeys))
+	name := stateString(row, nameKeys)
+	if err != nil || rank <= 0 || name == "" {
+		return chart.Entry{}, false
+	}
+
+	e := chart.Entry{
+		Rank:      rank,
+		Name:      name,
+		AppID:     stateString(row, appIDKeys),
+		Developer: stateString(row, developerKeys),
+		Icon:      stateString(row, iconKeys),
+		IAP:       stateBool(row, iapKeys),
+	}
+	if e.AppID == "" {
+		e.AppID = AppIDFromHref(stateString(row, urlKeys))
+	}
+
+	price := stateString(row, priceKeys)
+	if value, err := strconv.ParseFloat(price, 64); err == nil {
+		if value == 0 {
+			price = "Free"
+		} else {
+			price = strings.TrimSpace(strconv.FormatFloat(value, 'f', 2, 64) + " " + stateString(row, currencyKeys))
+		}
+	}
+	e.Pricing = price
+
+	return e, true
+}
+
+// stateString returns the first of keys present in row as text. Objects such
+// as {"name": ...} or {"url": ...} are unwrapped.
+func stateString(row map[string]any, keys []string) string {
+	for _, key := range keys {
+		switch v := row[key].(type) {
+		case string:
+			if v = strings.TrimSpace(v); v != "" {
+				return v
+			}
+		case json.Number:
+			return v.String()
+		case map[string]any:
+			if s := stateString(v, []string{"name", "url", "src"}); s != "" {
+				return s
+			}
+		}
+	}
+	return ""
+}
+
+// stateBool reports whether the first of keys present in row is true.
+func stateBool(row map[string]any, keys []string) bool {
+	for _, key := range keys {
+		if v, ok := row[key].(bool); ok {
+			return v
+		}
+	}
+	return false
+}
@@ -0,0 +1,164 @@
+package appfigures
+
+import (
+	"log"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+
+	"myproject/internal/chart"
+)
+
+var (
+	// Regular expression to clean up the rank and name text
+	cleanupRegex = regexp.MustCompile(`<!--.*?-->`)
+	// Link text is "12. Name"; only the first period after the digits
+	// separates them, so names like "Dr. Wallet" survive
+	rankNameRegex = regexp.MustCompile(`^(\d+)\.\s*(.+)$`)
+	// Prices shown in a row: "Free", "$4.99", "£2.99", "4,99 €"
+	priceRegex = regexp.MustCompile(`(?i)\bfree\b|[$£€¥]\s?\d+(?:[.,]\d{2})?|\d+(?:[.,]\d{2})?\s?[$£€¥]`)
+	iapRegex   = regexp.MustCompile(`(?i)in-app purchases`)
+)
+
+// ParseHTML parses a rendered chart page. The page's embedded state and the
+// visible links, read with the first profile that yields a chart, are both
+// parsed. The state only holds the rows the page first loaded, so when
+// scrolling showed more, the state's rows are merged into the links' by rank.
+// It returns a *DriftError when neither yields a chart.
+func ParseHTML(html string) ([]chart.Entry, error) {
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader(html))
+	if err != nil {
+		return nil, err
+	}
+
+	drift := &DriftError{Problems: make(map[string]error)}
+
+	state := ParseState(doc)
+	if err := CheckDrift(state); err != nil {
+		drift.Problems["embedded-state"] = err
+		state = nil
+	}
+
+	var links []chart.Entry
+	for i, p := range Profiles {
+		entries := Parse(doc, p)
+		if err := CheckDrift(entries); err != nil {
+			drift.Problems[p.Name] = err
+			continue
+		}
+		if i > 0 {
+			log.Printf("Chart markup matched fallback profile %s", p.Name)
+		}
+		links = entries
+		break
+	}
+
+	switch {
+	case state == nil && links == nil:
+		return nil, drift
+	case len(state) >= len(links):
+		return state, nil
+	}
+	return mergeByRank(state, links), nil
+}
+
+// mergeByRank returns links with the entries of state in place of those of
+// the same rank.
+func mergeByRank(state, links []chart.Entry) []chart.Entry {
+	byRank := make(map[int]chart.Entry, len(state))
+	for _, e := range state {
+		byRank[e.Rank] = e
+	}
+	merged := make([]chart.Entry, len(links))
+	for i, e := range links {
+		if s, ok := byRank[e.Rank]; ok {
+			e = s
+		}
+		merged[i] = e
+	}
+	return merged
+}
+
+// Parse extracts chart entries from the rank-and-name links that profile p
+// describes. Links whose text doesn't look like "12. Name" are skipped.
+func Parse(doc *goquery.Document, p Profile) []chart.Entry {
+	links := doc.Selection
+	if p.Block != "" {
+		links = doc.Find(p.Block)
+	}
+
+	var entries []chart.Entry
+	links.Find(p.Link).Each(func(i int, s *goquery.Selection) {
+		entry, ok := parseLink(s)
+		if ok {
+			parseRow(&entry, row(s, p), p)
+			entries = append(entries, entry)
+		}
+	})
+	return entries
+}
+
+// row returns the chart row around link s.
+func row(s *goquery.Selection, p Profile) *goquery.Selection {
+	if p.Block != "" {
+		return s.Closest(p.Block)
+	}
+	return s.Parent()
+}
+
+// parseRow fills in the details shown around the rank-and-name link: icon,
+// developer, price and whether the app offers in-app purchases.
+func parseRow(e *chart.Entry, row *goquery.Selection, p Profile) {
+	e.Icon = row.Find("img").First().AttrOr("src", "")
+	if p.Developer != "" {
+		e.Developer = cleanText(row.Find(p.Developer).First().Text())
+	}
+
+	// Price and IAP are plain text; leave the name and developer out so an
+	// app called "Free Budget" isn't taken for a price
+	rest := row.Clone()
+	rest.Find(p.Link).Remove()
+	if p.Developer != "" {
+		rest.Find(p.Developer).Remove()
+	}
+	text := cleanText(rest.Text())
+	if price := priceRegex.FindString(text); strings.EqualFold(price, "free") {
+		e.Pricing = "Free"
+	} else {
+		e.Pricing = price
+	}
+	e.IAP = iapRegex.MatchString(text)
+}
+
+func parseLink(s *goquery.Selection) (chart.Entry, bool) {
+	text := cleanText(s.Text())
+	if text == "" {
+		return chart.Entry{}, false
+	}
+
+	parts := rankNameRegex.FindStringSubmatch(text)
+	if parts == nil {
+		return chart.Entry{}, false
+	}
+
+	rank, err := strconv.Atoi(parts[1])
+	if err != nil {
+		return chart.Entry{}, false
+	}
+
+	return chart.Entry{
+		Rank:  rank,
+		AppID: AppIDFromHref(s.AttrOr("href", "")),
+		Name:  parts[2],
+		Title: s.AttrOr("title", ""),
+	}, true
+}
+
+func cleanText(text string) string {
+	// Remove HTML comments
+	text = cleanupRegex.ReplaceAllString(text, "")
+	// Remove extra spaces and trim
+	return strings.Join(strings.Fields(text), " ")
+}
@@ -0,0 +1,121 @@
+package appfigures
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"myproject/internal/chart"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden files in testdata")
+
+// TestParseHTMLGolden parses each recorded page in testdata and compares the
+// entries with <page>.golden.json. Run with -update after a deliberate parser
+// change to regenerate them.
+func TestParseHTMLGolden(t *testing.T) {
+	tests := []struct {
+		page    string
+		profile string // layout the page was recorded in, for the log only
+	}{
+		{page: "ios_united-states_2024-10-28", profile: "2024-10-28"},
+		{page: "ios_united-kingdom_2024-10-21", profile: "2024-10-21"},
+		{page: "play_united-states_2024-10-28", profile: "2024-10-28"},
+		{page: "play_united-kingdom_2024-10-21", profile: "2024-10-21"},
+		{page: "ios_united-states_state", profile: "embedded-state"},
+		// Scrolled past the rows the embedded state holds
+		{page: "ios_united-states_scrolled", profile: "2024-10-28"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.page, func(t *testing.T) {
+			html, err := os.ReadFile(filepath.Join("testdata", tt.page+".html"))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			got, err := ParseHTML(string(html))
+			if err != nil {
+				t.Fatalf("ParseHTML (%s layout): %v", tt.profile, err)
+			}
+
+			golden := filepath.Join("testdata", tt.page+".golden.json")
+			if *update {
+				data, err := json.MarshalIndent(got, "", "  ")
+				if err != nil {
+					t.Fatal(err)
+				}
+				if err := os.WriteFile(golden, append(data, '\n'), 0o644); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			data, err := os.ReadFile(golden)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var want []chart.Entry
+			if err := json.Unmarshal(data, &want); err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("entries differ from %s\ngot:  %+v\nwant: %+v", golden, got, want)
+			}
+		})
+	}
+}
+
+func TestParseHTMLDrift(t *testing.T) {
+	html, err := os.ReadFile(filepath.Join("testdata", "drift_2024-11.html"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = ParseHTML(string(html))
+	var drift *DriftError
+	if !errors.As(err, &drift) {
+		t.Fatalf("ParseHTML error = %v, want a *DriftError", err)
+	}
+	for _, p := range Profiles {
+		if drift.Problems[p.Name] == nil {
+			t.Errorf("no problem reported for profile %s", p.Name)
+		}
+	}
+}
+
+func TestCheckDrift(t *testing.T) {
+	tests := []struct {
+		name    string
+		ranks   []int
+		wantErr string
+	}{
+		{name: "contiguous", ranks: []int{1, 2, 3}},
+		{name: "out of order", ranks: []int{2, 1, 3}},
+		{name: "empty", wantErr: "no entries"},
+		{name: "gap", ranks: []int{1, 2, 4}, wantErr: "expected #3, got #4"},
+		{name: "repeat", ranks: []int{1, 2, 2}, wantErr: "expected #3, got #2"},
+		{name: "not from the top", ranks: []int{2, 3}, wantErr: "expected #1, got #2"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var entries []chart.Entry
+			for _, r := range tt.ranks {
+				entries = append(entries, chart.Entry{Rank: r, Name: "App"})
+			}
+
+			err := CheckDrift(entries)
+			switch {
+			case tt.wantErr == "" && err != nil:
+				t.Errorf("CheckDrift = %v, want nil", err)
+			case tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)):
+				t.Errorf("CheckDrift = %v, want error containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
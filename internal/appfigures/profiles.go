@@ -0,0 +1,85 @@
+package appfigures
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"myproject/internal/chart"
+)
+
+// Profile is one known version of the chart markup. The class names are
+// generated by the site and change from time to time, so each layout seen in
+// the wild gets its own profile rather than being edited in place.
+type Profile struct {
+	Name      string // when the layout was first seen
+	Block     string // one chart row; empty to look for links anywhere on the page
+	Link      string // the "12. Name" link inside a row
+	Developer string // the developer link inside a row, if the layout has one
+}
+
+// Profiles are the known layouts, newest first. Parsing tries them in order.
+var Profiles = []Profile{
+	{Name: "2024-10-28", Block: "div.s-1362551351-0", Link: "a.s-4262409-0", Developer: developerLink},
+	{Name: "2024-10-21", Block: "div.s445742525-0", Link: "a.s-4262409-0", Developer: developerLink},
+	{Name: "links-only", Link: "a.s-4262409-0"},
+}
+
+// developerLink matches links to a developer's page, which have kept their
+// path while the class names changed.
+const developerLink = `a[href*="/developers/"]`
+
+// LinkSelector matches the rank-and-name link of any known profile. The
+// browser waits for it and counts it while scrolling.
+var LinkSelector = linkSelector(Profiles)
+
+func linkSelector(profiles []Profile) string {
+	var links []string
+	seen := make(map[string]bool)
+	for _, p := range profiles {
+		if !seen[p.Link] {
+			seen[p.Link] = true
+			links = append(links, p.Link)
+		}
+	}
+	return strings.Join(links, ", ")
+}
+
+// DriftError reports a page that loaded but that no profile could parse.
+type DriftError struct {
+	Problems map[string]error // keyed by profile name
+}
+
+func (e *DriftError) Error() string {
+	names := make([]string, 0, len(e.Problems))
+	for name := range e.Problems {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var parts []string
+	for _, name := range names {
+		parts = append(parts, fmt.Sprintf("%s: %v", name, e.Problems[name]))
+	}
+	return "chart markup drifted from every known profile (" + strings.Join(parts, "; ") + ")"
+}
+
+// CheckDrift reports whether entries look like a whole chart: at least one
+// entry, with ranks running 1, 2, 3... without gaps or repeats.
+func CheckDrift(entries []chart.Entry) error {
+	if len(entries) == 0 {
+		return fmt.Errorf("no entries matched")
+	}
+
+	ranks := make([]int, len(entries))
+	for i, e := range entries {
+		ranks[i] = e.Rank
+	}
+	sort.Ints(ranks)
+	for i, rank := range ranks {
+		if rank != i+1 {
+			return fmt.Errorf("ranks not contiguous: expected #%d, got #%d", i+1, rank)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,65 @@
+package appfigures
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"myproject/internal/chart"
+	"myproject/internal/fetch"
+	"myproject/internal/metrics"
+	"myproject/internal/stage"
+)
+
+// Source reads one store's charts from appfigures.com.
+type Source struct {
+	store  string
+	loader fetch.Loader
+}
+
+// NewIOSSource returns a source for App Store charts.
+func NewIOSSource(loader fetch.Loader) *Source {
+	return &Source{store: chart.StoreIOS, loader: loader}
+}
+
+// NewPlaySource returns a source for Google Play charts.
+func NewPlaySource(loader fetch.Loader) *Source {
+	return &Source{store: chart.StorePlay, loader: loader}
+}
+
+// Name implements chart.Source.
+func (s *Source) Name() string {
+	return "appfigures-" + s.store
+}
+
+// Fetch implements chart.Source.
+func (s *Source) Fetch(ctx context.Context, req chart.Request) (*chart.Chart, error) {
+	if req.Store != s.store {
+		return nil, fmt.Errorf("%s cannot fetch %s charts", s.Name(), req.Store)
+	}
+
+	html, err := s.loader.Load(chart.WithRequest(ctx, req), ChartURL(req))
+	if err != nil {
+		return nil, err
+	}
+
+	start := time.Now()
+	entries, err := ParseHTML(html)
+	metrics.ObserveStage(metrics.StageParse, start)
+	if err != nil {
+		var drift *DriftError
+		if errors.As(err, &drift) {
+			metrics.SelectorMisses.WithLabelValues("parse").Inc()
+		}
+		return nil, stage.Wrap(stage.Parse, fmt.Errorf("parse %s: %w", req, err))
+	}
+
+	return &chart.Chart{
+		Request:   req,
+		Source:    s.Name(),
+		FetchedAt: time.Now(),
+		Entries:   entries,
+		Depth:     chart.LoadedDepth(entries),
+	}, nil
+}
@@ -0,0 +1,121 @@
+// Package applerss reads iOS top charts from Apple's public RSS feeds in
+// their JSON form, e.g.
+// https://itunes.apple.com/us/rss/topfreeapplications/limit=200/genre=6015/json.
+package applerss
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"myproject/internal/catalog"
+	"myproject/internal/chart"
+	"myproject/internal/fetch"
+	"myproject/internal/stage"
+)
+
+// DefaultBaseURL is where the live feeds are served from.
+const DefaultBaseURL = "https://itunes.apple.com"
+
+// MaxLimit is the deepest chart the feed will return.
+const MaxLimit = 200
+
+// feedNames maps device and list to the feed name. There is no iPad feed of
+// new apps.
+var feedNames = map[string]map[string]string{
+	chart.DeviceIPhone: {
+		chart.ListFree:     "topfreeapplications",
+		chart.ListPaid:     "toppaidapplications",
+		chart.ListGrossing: "topgrossingapplications",
+		chart.ListNew:      "newapplications",
+	},
+	chart.DeviceIPad: {
+		chart.ListFree:     "topfreeipadapplications",
+		chart.ListPaid:     "toppaidipadapplications",
+		chart.ListGrossing: "topgrossingipadapplications",
+	},
+}
+
+// Source reads charts from the RSS feed.
+type Source struct {
+	BaseURL string
+	Client  *http.Client
+	Limit   int
+}
+
+// NewSource returns a source reading the live feeds at their full depth.
+func NewSource() *Source {
+	return &Source{BaseURL: DefaultBaseURL, Client: fetch.NewClient(), Limit: MaxLimit}
+}
+
+// Name implements chart.Source.
+func (s *Source) Name() string {
+	return "apple-rss"
+}
+
+// FeedURL returns the feed address for req.
+func (s *Source) FeedURL(req chart.Request) (string, error) {
+	device := req.Device
+	if device == "" {
+		device = chart.DefaultDevice(chart.StoreIOS)
+	}
+	feed, ok := feedNames[device][req.List]
+	if !ok {
+		return "", fmt.Errorf("apple-rss has no %q list for %s", req.List, device)
+	}
+	category, ok := catalog.LookupCategory(chart.StoreIOS, req.Category)
+	if !ok {
+		return "", fmt.Errorf("apple-rss has no %q genre", req.Category)
+	}
+	country, ok := catalog.LookupCountry(req.Country)
+	if !ok {
+		return "", fmt.Errorf("apple-rss has no %q storefront", req.Country)
+	}
+
+	limit := s.Limit
+	if limit <= 0 || limit > MaxLimit {
+		limit = MaxLimit
+	}
+
+	return fmt.Sprintf("%s/%s/rss/%s/limit=%d/genre=%s/json",
+		strings.TrimRight(s.BaseURL, "/"), country.Storefront, feed, limit, category.AppleGenreID), nil
+}
+
+// Fetch implements chart.Source.
+func (s *Source) Fetch(ctx context.Context, req chart.Request) (*chart.Chart, error) {
+	if req.Store != chart.StoreIOS {
+		return nil, fmt.Errorf("%s cannot fetch %s charts", s.Name(), req.Store)
+	}
+
+	url, err := s.FeedURL(req)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := fetch.Get(ctx, s.Client, url)
+	if err != nil {
+		return nil, stage.Wrap(stage.Network, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, stage.StatusError(resp.StatusCode, resp.Status)
+	}
+
+	var f feed
+	if err := json.NewDecoder(resp.Body).Decode(&f); err != nil {
+		return nil, stage.Wrap(stage.Parse, fmt.Errorf("decode %s: %w", req, err))
+	}
+
+	entries := f.entries()
+	return &chart.Chart{
+		Request:   req,
+		Source:    s.Name(),
+		FetchedAt: time.Now(),
+		Entries:   entries,
+		Depth:     chart.LoadedDepth(entries),
+	}, nil
+}
@@ -0,0 +1,130 @@
+package applerss
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"myproject/internal/chart"
+	"myproject/internal/stage"
+)
+
+// newFeedServer serves testdata/<country>_<feed>_<genre>.json for
+// /<country>/rss/<feed>/limit=<n>/genre=<genre>/json.
+func newFeedServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		parts := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+		if len(parts) != 6 || parts[1] != "rss" || parts[5] != "json" {
+			http.NotFound(w, r)
+			return
+		}
+		genre := strings.TrimPrefix(parts[4], "genre=")
+		http.ServeFile(w, r, filepath.Join("testdata", parts[0]+"_"+parts[2]+"_"+genre+".json"))
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestFetch(t *testing.T) {
+	srv := newFeedServer(t)
+	src := &Source{BaseURL: srv.URL, Client: srv.Client(), Limit: 100}
+
+	tests := []struct {
+		name    string
+		req     chart.Request
+		count   int
+		want    chart.Entry
+		wantIdx int
+	}{
+		{
+			name:  "free chart",
+			req:   chart.Request{Store: chart.StoreIOS, Country: "united-states", Category: "finance", List: "free"},
+			count: 8,
+			want: chart.Entry{
+				Rank:      8,
+				AppID:     "886427730",
+				Name:      "Coinbase: Buy Bitcoin & Ether",
+				Title:     "Coinbase: Buy Bitcoin & Ether - Coinbase, Inc.",
+				Developer: "Coinbase, Inc.",
+				Pricing:   "Free",
+				Icon:      "https://is1-ssl.mzstatic.com/image/thumb/Purple/53x53bb.png",
+			},
+			wantIdx: 7,
+		},
+		{
+			name:  "single entry paid chart",
+			req:   chart.Request{Store: chart.StoreIOS, Country: "GB", Category: "finance", List: "paid"},
+			count: 1,
+			want: chart.Entry{
+				Rank:      1,
+				AppID:     "1052238659",
+				Name:      "Monzo - Mobile Banking",
+				Title:     "Monzo - Mobile Banking - Monzo Bank Ltd",
+				Developer: "Monzo Bank Ltd",
+				Pricing:   "2.99 GBP",
+				Icon:      "https://is1-ssl.mzstatic.com/image/thumb/Purple/53x53bb.png",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, err := src.Fetch(context.Background(), tt.req)
+			if err != nil {
+				t.Fatalf("Fetch: %v", err)
+			}
+			if len(c.Entries) != tt.count {
+				t.Fatalf("got %d entries, want %d", len(c.Entries), tt.count)
+			}
+			if got := c.Entries[tt.wantIdx]; got != tt.want {
+				t.Errorf("entry %d = %+v, want %+v", tt.wantIdx, got, tt.want)
+			}
+			for i, e := range c.Entries {
+				if e.Rank != i+1 {
+					t.Errorf("entry %d has rank %d", i, e.Rank)
+				}
+			}
+		})
+	}
+}
+
+func TestFetchRejectsUnknownList(t *testing.T) {
+	src := &Source{BaseURL: "http://127.0.0.1:0"}
+	_, err := src.Fetch(context.Background(), chart.Request{Store: chart.StoreIOS, Country: "us", Category: "finance", List: "trending"})
+	if err == nil {
+		t.Fatal("expected an error for an unsupported list")
+	}
+}
+
+// TestFetchStatus checks that a missing feed isn't worth retrying but a
+// server error is.
+func TestFetchStatus(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasPrefix(r.URL.Path, "/gb/") {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		http.NotFound(w, r)
+	}))
+	defer srv.Close()
+	src := &Source{BaseURL: srv.URL, Client: srv.Client(), Limit: 100}
+
+	tests := []struct {
+		country   string
+		stage     stage.Stage
+		transient bool
+	}{
+		{"US", stage.Rejected, false},
+		{"GB", stage.Network, true},
+	}
+	for _, tt := range tests {
+		_, err := src.Fetch(context.Background(), chart.Request{Store: chart.StoreIOS, Country: tt.country, Category: "finance", List: "free"})
+		if got := stage.Of(err); got != tt.stage || stage.Transient(err) != tt.transient {
+			t.Errorf("%s: error %v has stage %q, want %q", tt.country, err, got, tt.stage)
+		}
+	}
+}
@@ -0,0 +1,98 @@
+package applerss
+
+import (
+	"encoding/json"
+	"strconv"
+
+	"myproject/internal/chart"
+)
+
+// The feed wraps every value in {"label": ...} and puts IDs in attributes.
+type label struct {
+	Label string `json:"label"`
+}
+
+type feed struct {
+	Feed struct {
+		Entry entryList `json:"entry"`
+	} `json:"feed"`
+}
+
+type feedEntry struct {
+	Name   label   `json:"im:name"`
+	Title  label   `json:"title"`
+	Artist label   `json:"im:artist"`
+	Image  []label `json:"im:image"` // smallest first
+	Price  struct {
+		Label      string `json:"label"`
+		Attributes struct {
+			Amount   string `json:"amount"`
+			Currency string `json:"currency"`
+		} `json:"attributes"`
+	} `json:"im:price"`
+	ID struct {
+		Label      string `json:"label"`
+		Attributes struct {
+			ID       string `json:"im:id"`
+			BundleID string `json:"im:bundleId"`
+		} `json:"attributes"`
+	} `json:"id"`
+}
+
+// entryList copes with the feed returning a bare object instead of an array
+// when a chart has a single entry.
+type entryList []feedEntry
+
+func (l *entryList) UnmarshalJSON(data []byte) error {
+	if len(data) > 0 && data[0] == '{' {
+		var e feedEntry
+		if err := json.Unmarshal(data, &e); err != nil {
+			return err
+		}
+		*l = entryList{e}
+		return nil
+	}
+	var entries []feedEntry
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return err
+	}
+	*l = entries
+	return nil
+}
+
+// entries ranks the feed in the order Apple lists it.
+func (f *feed) entries() []chart.Entry {
+	entries := make([]chart.Entry, 0, len(f.Feed.Entry))
+	for i, e := range f.Feed.Entry {
+		entries = append(entries, chart.Entry{
+			Rank:      i + 1,
+			AppID:     e.ID.Attributes.ID,
+			Name:      e.Name.Label,
+			Title:     e.Title.Label,
+			Developer: e.Artist.Label,
+			Pricing:   pricing(e.Price.Attributes.Amount, e.Price.Attributes.Currency, e.Price.Label),
+			Icon:      e.icon(),
+		})
+	}
+	return entries
+}
+
+// icon returns the largest image the feed lists.
+func (e feedEntry) icon() string {
+	if len(e.Image) == 0 {
+		return ""
+	}
+	return e.Image[len(e.Image)-1].Label
+}
+
+// pricing renders the price as "Free" or "1.99 USD".
+func pricing(amount, currency, fallback string) string {
+	value, err := strconv.ParseFloat(amount, 64)
+	if err != nil {
+		return fallback
+	}
+	if value == 0 {
+		return "Free"
+	}
+	return strconv.FormatFloat(value, 'f', 2, 64) + " " + currency
+}
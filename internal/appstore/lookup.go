@@ -0,0 +1,102 @@
+package appstore
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"myproject/internal/fetch"
+)
+
+// DefaultBaseURL serves the iTunes lookup and search APIs.
+const DefaultBaseURL = "https://itunes.apple.com"
+
+// App is the metadata the lookup API returns for one app.
+type App struct {
+	TrackID     int64     `json:"trackId"`
+	Name        string    `json:"trackName"`
+	BundleID    string    `json:"bundleId"`
+	Seller      string    `json:"sellerName"`
+	Price       float64   `json:"price"`
+	Currency    string    `json:"currency"`
+	Genre       string    `json:"primaryGenreName"`
+	Rating      float64   `json:"averageUserRating"`
+	RatingCount int       `json:"userRatingCount"`
+	Version     string    `json:"version"`
+	ReleaseDate time.Time `json:"releaseDate"`
+	URL         string    `json:"trackViewUrl"`
+}
+
+// Client queries the iTunes lookup and search APIs.
+type Client struct {
+	BaseURL string
+	HTTP    *http.Client
+}
+
+// NewClient returns a client for the live API.
+func NewClient() *Client {
+	return &Client{BaseURL: DefaultBaseURL, HTTP: fetch.NewClient()}
+}
+
+type results struct {
+	ResultCount int   `json:"resultCount"`
+	Results     []App `json:"results"`
+}
+
+// Lookup resolves track IDs to app metadata in the given storefront
+// ("us", "gb"). IDs the store doesn't know are missing from the result.
+func (c *Client) Lookup(ctx context.Context, country string, ids ...string) (map[string]App, error) {
+	q := url.Values{}
+	q.Set("id", strings.Join(ids, ","))
+	q.Set("country", country)
+	q.Set("entity", "software")
+
+	var res results
+	if err := c.get(ctx, "/lookup", q, &res); err != nil {
+		return nil, err
+	}
+
+	apps := make(map[string]App, len(res.Results))
+	for _, app := range res.Results {
+		apps[fmt.Sprint(app.TrackID)] = app
+	}
+	return apps, nil
+}
+
+// Search returns up to limit apps matching term in the given storefront.
+func (c *Client) Search(ctx context.Context, country, term string, limit int) ([]App, error) {
+	q := url.Values{}
+	q.Set("term", term)
+	q.Set("country", country)
+	q.Set("entity", "software")
+	q.Set("limit", fmt.Sprint(limit))
+
+	var res results
+	if err := c.get(ctx, "/search", q, &res); err != nil {
+		return nil, err
+	}
+	return res.Results, nil
+}
+
+func (c *Client) get(ctx context.Context, path string, q url.Values, v any) error {
+	u := strings.TrimRight(c.BaseURL, "/") + path + "?" + q.Encode()
+
+	resp, err := fetch.Get(ctx, c.HTTP, u)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("status code error: %d %s", resp.StatusCode, resp.Status)
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		return fmt.Errorf("decode %s: %w", path, err)
+	}
+	return nil
+}
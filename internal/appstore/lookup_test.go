@@ -0,0 +1,111 @@
+package appstore
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestLookup(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/lookup" {
+			http.NotFound(w, r)
+			return
+		}
+		q := r.URL.Query()
+		if q.Get("id") != "886427730,1288339409,1" || q.Get("country") != "us" {
+			t.Errorf("unexpected query %s", r.URL.RawQuery)
+		}
+		http.ServeFile(w, r, "testdata/lookup_us.json")
+	}))
+	defer srv.Close()
+
+	c := &Client{BaseURL: srv.URL, HTTP: srv.Client()}
+	apps, err := c.Lookup(context.Background(), "us", "886427730", "1288339409", "1")
+	if err != nil {
+		t.Fatalf("Lookup: %v", err)
+	}
+	if len(apps) != 2 {
+		t.Fatalf("got %d apps, want 2", len(apps))
+	}
+
+	got := apps["886427730"]
+	want := App{
+		TrackID:     886427730,
+		Name:        "Coinbase: Buy Bitcoin & Ether",
+		BundleID:    "com.vilcsak.bitcoin2",
+		Seller:      "Coinbase, Inc.",
+		Currency:    "USD",
+		Genre:       "Finance",
+		Rating:      4.67954,
+		RatingCount: 2074151,
+		Version:     "12.49.5",
+		ReleaseDate: time.Date(2014, 6, 19, 1, 44, 3, 0, time.UTC),
+		URL:         "https://apps.apple.com/us/app/coinbase-buy-bitcoin-ether/id886427730?uo=4",
+	}
+	if got != want {
+		t.Errorf("got %+v\nwant %+v", got, want)
+	}
+	if _, ok := apps["1"]; ok {
+		t.Error("unknown ID should be missing from the result")
+	}
+}
+
+func TestLookupStatusError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "slow down", http.StatusForbidden)
+	}))
+	defer srv.Close()
+
+	c := &Client{BaseURL: srv.URL, HTTP: srv.Client()}
+	if _, err := c.Lookup(context.Background(), "us", "886427730"); err == nil {
+		t.Fatal("expected an error for a 403 response")
+	}
+}
+
+func TestSearch(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/search" {
+			http.NotFound(w, r)
+			return
+		}
+		q := r.URL.Query()
+		if q.Get("term") != "crypto wallet" || q.Get("country") != "gb" || q.Get("entity") != "software" || q.Get("limit") != "2" {
+			t.Errorf("unexpected query %s", r.URL.RawQuery)
+		}
+		http.ServeFile(w, r, "testdata/search_gb.json")
+	}))
+	defer srv.Close()
+
+	c := &Client{BaseURL: srv.URL, HTTP: srv.Client()}
+	apps, err := c.Search(context.Background(), "gb", "crypto wallet", 2)
+	if err != nil {
+		t.Fatalf("Search: %v", err)
+	}
+	if len(apps) != 2 {
+		t.Fatalf("got %d apps, want 2", len(apps))
+	}
+
+	// Results keep the store's order
+	if apps[0].TrackID != 1327268470 || apps[1].TrackID != 1288339409 {
+		t.Errorf("got track IDs %d, %d", apps[0].TrackID, apps[1].TrackID)
+	}
+	want := App{
+		TrackID:     1288339409,
+		Name:        "Trust: Crypto & Bitcoin Wallet",
+		BundleID:    "com.sixdays.trust",
+		Seller:      "Six Days LLC",
+		Currency:    "GBP",
+		Genre:       "Finance",
+		Rating:      4.72,
+		RatingCount: 9120,
+		Version:     "10.28",
+		ReleaseDate: time.Date(2017, 11, 8, 23, 3, 29, 0, time.UTC),
+		URL:         "https://apps.apple.com/gb/app/trust-crypto-bitcoin-wallet/id1288339409?uo=4",
+	}
+	if apps[1] != want {
+		t.Errorf("got %+v\nwant %+v", apps[1], want)
+	}
+}
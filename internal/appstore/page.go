@@ -0,0 +1,74 @@
+// Package appstore reads app details from Apple's App Store pages and the
+// iTunes lookup and search APIs.
+package appstore
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+
+	"myproject/internal/chart"
+	"myproject/internal/fetch"
+)
+
+// BadgeList is the chart list a category rank badge is recorded under. The
+// badge doesn't name its list; it ranks a free app in its category's top free
+// chart, and every app we track is free.
+const BadgeList = chart.ListFree
+
+// AppPage is what the public App Store product page tells us about an app.
+type AppPage struct {
+	Name         string
+	CategoryRank string // e.g. "#32 in Finance"
+}
+
+// ScrapeAppPage fetches an apps.apple.com product page and reads the app
+// name and its category rank badge.
+func ScrapeAppPage(url string) (AppPage, error) {
+	doc, err := fetch.Page(url)
+	if err != nil {
+		return AppPage{}, err
+	}
+
+	app := AppPage{}
+
+	// The title element also holds badges like "4+"; keep only its own text
+	nameElem := doc.Find("h1.product-header__title")
+	app.Name = strings.TrimSpace(nameElem.Contents().FilterFunction(func(i int, s *goquery.Selection) bool {
+		return goquery.NodeName(s) == "#text"
+	}).Text())
+
+	rankElem := doc.Find("a.inline-list__item")
+	app.CategoryRank = strings.TrimSpace(rankElem.Text())
+
+	return app, nil
+}
+
+var (
+	categoryRankRegex = regexp.MustCompile(`^#(\d+) in (.+)$`)
+	pageURLRegex      = regexp.MustCompile(`apps\.apple\.com/([a-z]{2})/app/(?:[^/]+/)?id(\d+)`)
+)
+
+// Rank splits a badge like "#32 in Finance" into 32 and "Finance".
+func (p AppPage) Rank() (int, string, bool) {
+	m := categoryRankRegex.FindStringSubmatch(p.CategoryRank)
+	if m == nil {
+		return 0, "", false
+	}
+	rank, err := strconv.Atoi(m[1])
+	if err != nil {
+		return 0, "", false
+	}
+	return rank, m[2], true
+}
+
+// ParsePageURL returns the storefront and track ID of a product page URL.
+func ParsePageURL(url string) (country, trackID string, ok bool) {
+	m := pageURLRegex.FindStringSubmatch(url)
+	if m == nil {
+		return "", "", false
+	}
+	return m[1], m[2], true
+}
@@ -0,0 +1,185 @@
+// Package catalog lists the countries and categories appcheck knows how to
+// chart, and how each source spells them.
+package catalog
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"myproject/internal/chart"
+)
+
+// Country is one storefront, identified by its ISO 3166-1 alpha-2 code.
+type Country struct {
+	Code         string // e.g. "US"; what requests and stored results use
+	Name         string // e.g. "United States"
+	Slug         string // appfigures URL segment, e.g. "united-states"
+	Storefront   string // App Store country code, e.g. "us"
+	StorefrontID int    // App Store storefront ID, e.g. 143441
+}
+
+var countries = []Country{
+	{Code: "US", Name: "United States", Slug: "united-states", Storefront: "us", StorefrontID: 143441},
+	{Code: "GB", Name: "United Kingdom", Slug: "united-kingdom", Storefront: "gb", StorefrontID: 143444},
+	{Code: "CA", Name: "Canada", Slug: "canada", Storefront: "ca", StorefrontID: 143455},
+	{Code: "AU", Name: "Australia", Slug: "australia", Storefront: "au", StorefrontID: 143460},
+	{Code: "NZ", Name: "New Zealand", Slug: "new-zealand", Storefront: "nz", StorefrontID: 143461},
+	{Code: "IE", Name: "Ireland", Slug: "ireland", Storefront: "ie", StorefrontID: 143449},
+	{Code: "DE", Name: "Germany", Slug: "germany", Storefront: "de", StorefrontID: 143443},
+	{Code: "FR", Name: "France", Slug: "france", Storefront: "fr", StorefrontID: 143442},
+	{Code: "ES", Name: "Spain", Slug: "spain", Storefront: "es", StorefrontID: 143454},
+	{Code: "IT", Name: "Italy", Slug: "italy", Storefront: "it", StorefrontID: 143450},
+	{Code: "NL", Name: "Netherlands", Slug: "netherlands", Storefront: "nl", StorefrontID: 143452},
+	{Code: "CH", Name: "Switzerland", Slug: "switzerland", Storefront: "ch", StorefrontID: 143459},
+	{Code: "SE", Name: "Sweden", Slug: "sweden", Storefront: "se", StorefrontID: 143456},
+	{Code: "JP", Name: "Japan", Slug: "japan", Storefront: "jp", StorefrontID: 143462},
+	{Code: "KR", Name: "South Korea", Slug: "south-korea", Storefront: "kr", StorefrontID: 143466},
+	{Code: "HK", Name: "Hong Kong", Slug: "hong-kong", Storefront: "hk", StorefrontID: 143463},
+	{Code: "SG", Name: "Singapore", Slug: "singapore", Storefront: "sg", StorefrontID: 143464},
+	{Code: "IN", Name: "India", Slug: "india", Storefront: "in", StorefrontID: 143467},
+	{Code: "BR", Name: "Brazil", Slug: "brazil", Storefront: "br", StorefrontID: 143503},
+	{Code: "MX", Name: "Mexico", Slug: "mexico", Storefront: "mx", StorefrontID: 143468},
+}
+
+// countryAliases are codes people use that aren't the ISO ones.
+var countryAliases = map[string]string{
+	"UK": "GB",
+}
+
+// Countries returns every known country.
+func Countries() []Country {
+	return slices.Clone(countries)
+}
+
+// LookupCountry finds a country by ISO code, appfigures slug, storefront code
+// or name, ignoring case.
+func LookupCountry(s string) (Country, bool) {
+	s = strings.TrimSpace(s)
+	if code, ok := countryAliases[strings.ToUpper(s)]; ok {
+		s = code
+	}
+	for _, c := range countries {
+		if strings.EqualFold(s, c.Code) || strings.EqualFold(s, c.Slug) || strings.EqualFold(s, c.Name) {
+			return c, true
+		}
+	}
+	return Country{}, false
+}
+
+// CountryCode returns the ISO code for s, or s unchanged when it isn't a
+// known country.
+func CountryCode(s string) string {
+	if c, ok := LookupCountry(s); ok {
+		return c.Code
+	}
+	return s
+}
+
+// Category is one chart category. Key doubles as the appfigures URL segment.
+type Category struct {
+	Key          string // e.g. "finance"
+	Name         string // e.g. "Finance"
+	AppleGenreID string // App Store genre ID; empty when the App Store has no such category
+	PlayID       string // Google Play category ID; empty when Play has no such category
+}
+
+var categories = []Category{
+	{Key: "finance", Name: "Finance", AppleGenreID: "6015", PlayID: "FINANCE"},
+	{Key: "business", Name: "Business", AppleGenreID: "6000", PlayID: "BUSINESS"},
+	{Key: "productivity", Name: "Productivity", AppleGenreID: "6007", PlayID: "PRODUCTIVITY"},
+	{Key: "education", Name: "Education", AppleGenreID: "6017", PlayID: "EDUCATION"},
+	{Key: "entertainment", Name: "Entertainment", AppleGenreID: "6016", PlayID: "ENTERTAINMENT"},
+	{Key: "games", Name: "Games", AppleGenreID: "6014", PlayID: "GAME"},
+	{Key: "health-and-fitness", Name: "Health & Fitness", AppleGenreID: "6013", PlayID: "HEALTH_AND_FITNESS"},
+	{Key: "lifestyle", Name: "Lifestyle", AppleGenreID: "6012", PlayID: "LIFESTYLE"},
+	{Key: "medical", Name: "Medical", AppleGenreID: "6020", PlayID: "MEDICAL"},
+	{Key: "music", Name: "Music", AppleGenreID: "6011", PlayID: "MUSIC_AND_AUDIO"},
+	{Key: "navigation", Name: "Navigation", AppleGenreID: "6010", PlayID: "MAPS_AND_NAVIGATION"},
+	{Key: "news", Name: "News", AppleGenreID: "6009", PlayID: "NEWS_AND_MAGAZINES"},
+	{Key: "photo-and-video", Name: "Photo & Video", AppleGenreID: "6008", PlayID: "PHOTOGRAPHY"},
+	{Key: "shopping", Name: "Shopping", AppleGenreID: "6024", PlayID: "SHOPPING"},
+	{Key: "social-networking", Name: "Social Networking", AppleGenreID: "6005", PlayID: "SOCIAL"},
+	{Key: "sports", Name: "Sports", AppleGenreID: "6004", PlayID: "SPORTS"},
+	{Key: "travel", Name: "Travel", AppleGenreID: "6003", PlayID: "TRAVEL_AND_LOCAL"},
+	{Key: "utilities", Name: "Utilities", AppleGenreID: "6002", PlayID: "TOOLS"},
+	{Key: "weather", Name: "Weather", AppleGenreID: "6001", PlayID: "WEATHER"},
+	{Key: "food-and-drink", Name: "Food & Drink", AppleGenreID: "6023", PlayID: "FOOD_AND_DRINK"},
+	{Key: "reference", Name: "Reference", AppleGenreID: "6006"},
+	{Key: "books", Name: "Books", AppleGenreID: "6018", PlayID: "BOOKS_AND_REFERENCE"},
+}
+
+// Categories returns every known category.
+func Categories() []Category {
+	return slices.Clone(categories)
+}
+
+// LookupCategory finds a category of store by key or name, ignoring case.
+func LookupCategory(store, s string) (Category, bool) {
+	s = strings.TrimSpace(s)
+	for _, c := range categories {
+		if !strings.EqualFold(s, c.Key) && !strings.EqualFold(s, c.Name) {
+			continue
+		}
+		if c.In(store) {
+			return c, true
+		}
+		return Category{}, false
+	}
+	return Category{}, false
+}
+
+// CategoryKey returns the catalogue key for a category key or name, or s
+// unchanged when it isn't a known category.
+func CategoryKey(s string) string {
+	for _, c := range categories {
+		if strings.EqualFold(s, c.Key) || strings.EqualFold(s, c.Name) {
+			return c.Key
+		}
+	}
+	return s
+}
+
+// In reports whether store has the category.
+func (c Category) In(store string) bool {
+	switch store {
+	case chart.StoreIOS:
+		return c.AppleGenreID != ""
+	case chart.StorePlay:
+		return c.PlayID != ""
+	}
+	return false
+}
+
+// Normalize returns req with its country as an ISO code and its category as
+// a catalogue key, or an error naming the first part that isn't supported.
+func Normalize(req chart.Request) (chart.Request, error) {
+	if req.Store != chart.StoreIOS && req.Store != chart.StorePlay {
+		return req, fmt.Errorf("unknown store %q", req.Store)
+	}
+
+	country, ok := LookupCountry(req.Country)
+	if !ok {
+		return req, fmt.Errorf("unknown country %q", req.Country)
+	}
+	req.Country = country.Code
+
+	category, ok := LookupCategory(req.Store, req.Category)
+	if !ok {
+		return req, fmt.Errorf("%s has no %q category", req.Store, req.Category)
+	}
+	req.Category = category.Key
+
+	if !slices.Contains(chart.Lists, req.List) {
+		return req, fmt.Errorf("unknown list %q, want one of %s", req.List, strings.Join(chart.Lists, ", "))
+	}
+
+	devices := chart.Devices(req.Store)
+	switch {
+	case req.Device == "" && len(devices) > 0:
+		req.Device = chart.DefaultDevice(req.Store)
+	case req.Device != "" && !slices.Contains(devices, req.Device):
+		return req, fmt.Errorf("%s has no %q device", req.Store, req.Device)
+	}
+	return req, nil
+}
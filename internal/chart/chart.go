@@ -0,0 +1,135 @@
+// Package chart defines top-chart requests and results shared by every chart
+// source.
+package chart
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// Stores a chart can come from.
+const (
+	StoreIOS  = "ios"
+	StorePlay = "play"
+)
+
+// Chart lists.
+const (
+	ListFree     = "free"
+	ListPaid     = "paid"
+	ListGrossing = "grossing"
+	ListNew      = "new"
+)
+
+// Lists are all the chart lists, in the order stores show them.
+var Lists = []string{ListFree, ListPaid, ListGrossing, ListNew}
+
+// Devices an App Store chart can be for. Play charts aren't split by device.
+const (
+	DeviceIPhone = "iphone"
+	DeviceIPad   = "ipad"
+)
+
+// Devices returns the devices store splits its charts by.
+func Devices(store string) []string {
+	if store == StoreIOS {
+		return []string{DeviceIPhone, DeviceIPad}
+	}
+	return nil
+}
+
+// DefaultDevice is the device a store's charts are for when none is given:
+// the iPhone for the App Store and none for Play.
+func DefaultDevice(store string) string {
+	if store == StoreIOS {
+		return DeviceIPhone
+	}
+	return ""
+}
+
+// Request identifies one chart.
+type Request struct {
+	Store    string `json:"store"`            // StoreIOS or StorePlay
+	Device   string `json:"device,omitempty"` // DeviceIPhone or DeviceIPad for StoreIOS; empty for StorePlay
+	Country  string `json:"country"`          // ISO 3166 code, e.g. "US"
+	Category string `json:"category"`         // catalogue key, e.g. "finance"
+	List     string `json:"list"`             // one of Lists
+}
+
+// String returns e.g. "ios/US/finance/free/iphone"; Play charts
+// have no device part.
+func (r Request) String() string {
+	s := fmt.Sprintf("%s/%s/%s/%s", r.Store, r.Country, r.Category, r.List)
+	if r.Device != "" {
+		s += "/" + r.Device
+	}
+	return s
+}
+
+type requestKey struct{}
+
+// WithRequest returns a context that carries req, so loaders further down can
+// tell which chart a page belongs to.
+func WithRequest(ctx context.Context, req Request) context.Context {
+	return context.WithValue(ctx, requestKey{}, req)
+}
+
+// RequestFrom returns the request stored by WithRequest.
+func RequestFrom(ctx context.Context) (Request, bool) {
+	req, ok := ctx.Value(requestKey{}).(Request)
+	return req, ok
+}
+
+// Entry is one ranked app in a chart.
+type Entry struct {
+	Rank      int
+	AppID     string // store ID or package name when the source exposes it
+	Name      string
+	Title     string // link title, which sometimes differs from Name
+	Developer string
+	Pricing   string // "Free" or the price as the source shows it
+	Icon      string // icon image URL
+	IAP       bool   // offers in-app purchases
+}
+
+// Chart is the result of fetching a Request.
+type Chart struct {
+	Request   Request
+	Source    string
+	FetchedAt time.Time
+	Entries   []Entry
+	Depth     int // how far down the chart the source loaded
+}
+
+// LoadedDepth returns the last rank reached without a gap from rank 1.
+func LoadedDepth(entries []Entry) int {
+	seen := make(map[int]bool, len(entries))
+	for _, e := range entries {
+		seen[e.Rank] = true
+	}
+	depth := 0
+	for seen[depth+1] {
+		depth++
+	}
+	return depth
+}
+
+// Top returns the entries ranked at or above limit.
+func (c *Chart) Top(limit int) []Entry {
+	var top []Entry
+	for _, e := range c.Entries {
+		if e.Rank <= limit {
+			top = append(top, e)
+		}
+	}
+	return top
+}
+
+// Source fetches ranked charts from one provider.
+type Source interface {
+	// Name identifies the source in logs and stored results.
+	Name() string
+	// Fetch loads the chart described by req.
+	Fetch(ctx context.Context, req Request) (*Chart, error)
+}
@@ -0,0 +1,191 @@
+// Package digest summarises a day of tracked ranks for the daily email: each
+// app's latest rank in each chart, the change since the day before and the
+// best and worst rank of the day.
+package digest
+
+import (
+	"bytes"
+	_ "embed"
+	htmltemplate "html/template"
+	"strconv"
+	"text/tabwriter"
+	"text/template"
+	"time"
+
+	"myproject/internal/match"
+	"myproject/internal/storage"
+	"myproject/internal/watchlist"
+)
+
+// Row is one app in one chart.
+type Row struct {
+	App   string
+	Chart string // chart heading, e.g. "United States - iOS App Store (finance, free, iphone)"
+
+	Latest   storage.Record // last usable record of the day; zero when there was none
+	Previous storage.Record // last usable record of the day before; zero when there was none
+	Best     int            // best rank of the day; 0 when never ranked
+	Worst    int            // worst rank of the day; 0 when never ranked
+}
+
+// Digest is a day's rows, grouped by app in watchlist order.
+type Digest struct {
+	Day  time.Time
+	Rows []Row
+}
+
+// Build summarises day from records, which should cover that day and the one
+// before. Records of failed charts are ignored. Records are matched to apps by
+// ID, so an app renamed in the store keeps one row under its watchlist name.
+// label names each chart.
+func Build(day time.Time, records []storage.Record, apps []watchlist.App, label func(storage.ChartKey) string) Digest {
+	start := time.Date(day.Year(), day.Month(), day.Day(), 0, 0, 0, 0, day.Location())
+	prevStart := start.AddDate(0, 0, -1)
+	end := start.AddDate(0, 0, 1)
+
+	type key struct {
+		appID string
+		chart storage.ChartKey
+	}
+	rows := make(map[key]*Row)
+	var charts []storage.ChartKey
+	seen := make(map[storage.ChartKey]bool)
+
+	for _, r := range records {
+		if r.Status == storage.StatusChartFailed || r.Time.Before(prevStart) || !r.Time.Before(end) {
+			continue
+		}
+		if !seen[r.Key()] {
+			seen[r.Key()] = true
+			charts = append(charts, r.Key())
+		}
+
+		k := key{r.AppID, r.Key()}
+		row := rows[k]
+		if row == nil {
+			row = &Row{Chart: label(r.Key())}
+			rows[k] = row
+		}
+
+		// Records arrive oldest first, so the last one seen is the latest
+		if r.Time.Before(start) {
+			row.Previous = r
+			continue
+		}
+		row.Latest = r
+		if r.Status == storage.StatusRanked {
+			if row.Best == 0 || r.Rank < row.Best {
+				row.Best = r.Rank
+			}
+			if r.Rank > row.Worst {
+				row.Worst = r.Rank
+			}
+		}
+	}
+
+	d := Digest{Day: start}
+	for _, app := range apps {
+		for _, c := range charts {
+			if row, ok := rows[key{match.RecordID(app, c.Store), c}]; ok && row.Latest.Status != "" {
+				row.App = app.Name
+				d.Rows = append(d.Rows, *row)
+			}
+		}
+	}
+	return d
+}
+
+// Subject is the email subject line.
+func (d Digest) Subject() string {
+	return "appcheck ranks for " + d.Day.Format("Mon 2 Jan 2006")
+}
+
+// Rank describes the latest position, e.g. "#12" or "below #200".
+func (r Row) Rank() string {
+	return describe(r.Latest)
+}
+
+// Change describes the move since the day before: "▲3" for up three places,
+// "▼15" for down fifteen, "=" for no change, or "" when either day wasn't
+// ranked.
+func (r Row) Change() string {
+	if r.Latest.Status != storage.StatusRanked || r.Previous.Status != storage.StatusRanked {
+		return ""
+	}
+	switch delta := r.Previous.Rank - r.Latest.Rank; {
+	case delta > 0:
+		return "▲" + strconv.Itoa(delta)
+	case delta < 0:
+		return "▼" + strconv.Itoa(-delta)
+	}
+	return "="
+}
+
+// Up reports whether the app moved up since the day before.
+func (r Row) Up() bool {
+	return r.Change() != "" && r.Latest.Rank < r.Previous.Rank
+}
+
+// Down reports whether the app moved down since the day before.
+func (r Row) Down() bool {
+	return r.Change() != "" && r.Latest.Rank > r.Previous.Rank
+}
+
+// BestRank and WorstRank describe the day's range, or "" when the app was
+// never ranked.
+func (r Row) BestRank() string  { return optionalRank(r.Best) }
+func (r Row) WorstRank() string { return optionalRank(r.Worst) }
+
+func optionalRank(rank int) string {
+	if rank == 0 {
+		return ""
+	}
+	return "#" + strconv.Itoa(rank)
+}
+
+func describe(r storage.Record) string {
+	switch {
+	case r.Status == storage.StatusRanked:
+		return "#" + strconv.Itoa(r.Rank)
+	case r.Status == storage.StatusBelowDepth && r.Depth > 0:
+		return "below #" + strconv.Itoa(r.Depth)
+	case r.Status == storage.StatusBelowDepth:
+		return "not in chart"
+	case r.Status == storage.StatusNotMatched:
+		return "unclear match"
+	}
+	return r.Status
+}
+
+var (
+	//go:embed digest.html
+	htmlSource string
+
+	htmlTemplate = htmltemplate.Must(htmltemplate.New("digest").Parse(htmlSource))
+	textTemplate = template.Must(template.New("digest").Parse(
+		"{{.Subject}}\n\n" +
+			"App\tChart\tRank\tChange\tBest\tWorst\n" +
+			"{{range .Rows}}{{.App}}\t{{.Chart}}\t{{.Rank}}\t{{.Change}}\t{{.BestRank}}\t{{.WorstRank}}\n{{end}}"))
+)
+
+// HTML renders the digest as an HTML email body.
+func (d Digest) HTML() (string, error) {
+	var buf bytes.Buffer
+	if err := htmlTemplate.Execute(&buf, d); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+// Text renders the digest as a plain-text table.
+func (d Digest) Text() (string, error) {
+	var buf bytes.Buffer
+	w := tabwriter.NewWriter(&buf, 0, 0, 2, ' ', 0)
+	if err := textTemplate.Execute(w, d); err != nil {
+		return "", err
+	}
+	if err := w.Flush(); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
@@ -0,0 +1,67 @@
+package digest
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"myproject/internal/storage"
+	"myproject/internal/watchlist"
+)
+
+func TestBuild(t *testing.T) {
+	day := time.Date(2024, 10, 28, 0, 0, 0, 0, time.UTC)
+	rec := func(hoursFromDay, rank int, status string) storage.Record {
+		return storage.Record{
+			Time: day.Add(time.Duration(hoursFromDay) * time.Hour), Store: "ios", Device: "iphone", Country: "US",
+			Category: "finance", List: "free", AppID: "886427730", AppName: "Coinbase", Rank: rank, Status: status, Depth: 200,
+		}
+	}
+	// Coinbase was listed under its old name the day before, and another app
+	// took the name since
+	renamed := rec(-2, 15, storage.StatusRanked)
+	renamed.AppName = "Coinbase: Buy Bitcoin & Ether"
+	impostor := rec(3, 1, storage.StatusRanked)
+	impostor.AppID = "999"
+
+	records := []storage.Record{
+		rec(-30, 5, storage.StatusRanked), // two days before: ignored
+		rec(-10, 20, storage.StatusRanked),
+		renamed, // previous day's latest
+		rec(1, 9, storage.StatusRanked),
+		impostor,
+		rec(5, 0, storage.StatusChartFailed),
+		rec(9, 30, storage.StatusRanked),
+		rec(20, 12, storage.StatusRanked), // latest
+		rec(26, 1, storage.StatusRanked),  // next day: ignored
+	}
+	apps := []watchlist.App{{Name: "Coinbase", Key: "Coinbase", IOSID: "886427730"}, {Name: "OKX", Key: "OKX"}}
+	label := func(k storage.ChartKey) string { return fmt.Sprintf("%s %s", k.Country, k.Store) }
+
+	d := Build(day, records, apps, label)
+	if len(d.Rows) != 1 {
+		t.Fatalf("got %d rows, want 1", len(d.Rows))
+	}
+	r := d.Rows[0]
+	got := []string{r.App, r.Chart, r.Rank(), r.Change(), r.BestRank(), r.WorstRank()}
+	want := []string{"Coinbase", "US ios", "#12", "▲3", "#9", "#30"}
+	if strings.Join(got, "|") != strings.Join(want, "|") {
+		t.Errorf("row = %q, want %q", got, want)
+	}
+
+	text, err := d.Text()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(text, "Coinbase  US ios  #12   ▲3") {
+		t.Errorf("text digest:\n%s", text)
+	}
+	html, err := d.HTML()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(html, "<td><b>#12</b></td>") || !strings.Contains(html, "#1a7f37") {
+		t.Errorf("html digest:\n%s", html)
+	}
+}
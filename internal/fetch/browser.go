@@ -0,0 +1,217 @@
+package fetch
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/chromedp/chromedp"
+
+	"myproject/internal/metrics"
+	"myproject/internal/stage"
+)
+
+// scrollScript jumps to the bottom of the page, which is what triggers the
+// next batch of lazily loaded rows.
+const scrollScript = `window.scrollTo(0, document.body.scrollHeight);`
+
+// RenderOptions controls how a page is loaded in the headless browser.
+type RenderOptions struct {
+	WaitSelector  string        // element that must be visible before scrolling
+	WaitTimeout   time.Duration // how long to wait for WaitSelector before giving up on the page
+	CountSelector string        // elements counted to tell whether scrolling loaded more
+	Depth         int           // stop once this many elements are present; 0 scrolls until stable
+	MaxScrolls    int           // ceiling on scroll passes
+	StableRounds  int           // stop after this many passes without new elements
+	Settle        time.Duration // extra wait after WaitSelector appears
+	ScrollPause   time.Duration // wait after each scroll pass
+	Timeout       time.Duration // overall deadline for the page
+}
+
+// DefaultRenderOptions waits for selector and keeps scrolling until 200 of
+// them are present or two passes in a row load nothing new.
+func DefaultRenderOptions(selector string) RenderOptions {
+	return RenderOptions{
+		WaitSelector:  selector,
+		WaitTimeout:   30 * time.Second,
+		CountSelector: selector,
+		Depth:         200,
+		MaxScrolls:    30,
+		StableRounds:  2,
+		Settle:        2 * time.Second,
+		ScrollPause:   2 * time.Second,
+		Timeout:       2 * time.Minute,
+	}
+}
+
+// Browser is one headless Chrome shared by every page load of a run. Each
+// load opens its own tab; at most Tabs of them are open at once.
+type Browser struct {
+	opts   RenderOptions
+	ctx    context.Context
+	cancel context.CancelFunc
+	tabs   chan struct{}
+}
+
+// NewBrowser starts Chrome and returns a browser allowing up to tabs
+// concurrent page loads.
+func NewBrowser(tabs int, opts RenderOptions) (*Browser, error) {
+	if tabs < 1 {
+		tabs = 1
+	}
+
+	allocCtx, cancelAlloc := chromedp.NewExecAllocator(context.Background(), chromedp.DefaultExecAllocatorOptions[:]...)
+	ctx, cancelBrowser := chromedp.NewContext(allocCtx)
+	cancel := func() {
+		cancelBrowser()
+		cancelAlloc()
+	}
+
+	// Running an empty task list launches the browser process up front, so a
+	// missing Chrome is reported here rather than on the first page
+	if err := chromedp.Run(ctx); err != nil {
+		cancel()
+		return nil, fmt.Errorf("start browser: %w", err)
+	}
+
+	return &Browser{opts: opts, ctx: ctx, cancel: cancel, tabs: make(chan struct{}, tabs)}, nil
+}
+
+// Load implements Loader. It waits for a free tab, renders url in it and
+// closes the tab again.
+func (b *Browser) Load(ctx context.Context, url string) (string, error) {
+	select {
+	case b.tabs <- struct{}{}:
+	case <-ctx.Done():
+		return "", ctx.Err()
+	}
+	defer func() { <-b.tabs }()
+
+	tabCtx, closeTab := chromedp.NewContext(b.ctx)
+	defer closeTab()
+	// Abandon the tab if the caller gives up
+	stop := context.AfterFunc(ctx, closeTab)
+	defer stop()
+
+	return render(tabCtx, url, b.opts)
+}
+
+// Close shuts the browser down.
+func (b *Browser) Close() {
+	b.cancel()
+}
+
+// render drives one tab through navigate, scroll and HTML extraction. Errors
+// are labelled with their stage: an error status is a rejection or, for
+// server errors, a network failure; a page whose rows never appear is a
+// selector-not-found; anything else a network failure or timeout. Each stage
+// is timed whether or not it succeeds.
+func render(ctx context.Context, url string, opts RenderOptions) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, opts.Timeout)
+	defer cancel()
+
+	// Navigate to the page
+	start := time.Now()
+	resp, err := chromedp.RunResponse(ctx, chromedp.Navigate(url))
+	if err != nil {
+		metrics.ObserveStage(metrics.StageNavigate, start)
+		return "", stage.Wrap(stage.Network, fmt.Errorf("navigate %s: %w", url, err))
+	}
+	if resp != nil && resp.Status >= 400 {
+		metrics.ObserveStage(metrics.StageNavigate, start)
+		return "", fmt.Errorf("navigate %s: %w", url, stage.StatusError(int(resp.Status), resp.StatusText))
+	}
+
+	// Wait for the chart rows to show up
+	err = waitVisible(ctx, opts)
+	metrics.ObserveStage(metrics.StageNavigate, start)
+	if err != nil {
+		if stage.Of(err) == stage.Selector {
+			metrics.SelectorMisses.WithLabelValues("render").Inc()
+		}
+		return "", fmt.Errorf("wait for %s on %s: %w", opts.WaitSelector, url, err)
+	}
+
+	// Scroll to pull in lazily loaded rows
+	start = time.Now()
+	count, scrolls, err := scrollUntilStable(ctx, opts)
+	metrics.ObserveStage(metrics.StageScroll, start)
+	if err != nil {
+		return "", stage.Wrap(stage.Network, fmt.Errorf("scroll %s: %w", url, err))
+	}
+	log.Printf("Loaded %d entries from %s after %d scrolls", count, url, scrolls)
+
+	// Extract the HTML content
+	var html string
+	start = time.Now()
+	err = chromedp.Run(ctx, chromedp.OuterHTML("body", &html))
+	metrics.ObserveStage(metrics.StageOuterHTML, start)
+	if err != nil {
+		return "", stage.Wrap(stage.Network, fmt.Errorf("extract html %s: %w", url, err))
+	}
+
+	return html, nil
+}
+
+// waitVisible waits up to opts.WaitTimeout for opts.WaitSelector and then
+// lets the page settle. Running out of that time is a selector-not-found;
+// running out of the page's overall time is a timeout.
+func waitVisible(ctx context.Context, opts RenderOptions) error {
+	waitCtx := ctx
+	if opts.WaitTimeout > 0 {
+		var cancel context.CancelFunc
+		waitCtx, cancel = context.WithTimeout(ctx, opts.WaitTimeout)
+		defer cancel()
+	}
+
+	err := chromedp.Run(waitCtx, chromedp.WaitVisible(opts.WaitSelector, chromedp.ByQuery))
+	if err != nil {
+		if ctx.Err() == nil && waitCtx.Err() != nil {
+			return stage.Wrap(stage.Selector, err)
+		}
+		return stage.Wrap(stage.Network, err)
+	}
+	if err := chromedp.Run(ctx, chromedp.Sleep(opts.Settle)); err != nil {
+		return stage.Wrap(stage.Network, err)
+	}
+	return nil
+}
+
+// scrollUntilStable scrolls until opts.Depth elements matching
+// opts.CountSelector are present, until opts.StableRounds passes in a row add
+// none, or until opts.MaxScrolls passes. It returns the final count and the
+// number of passes made.
+func scrollUntilStable(ctx context.Context, opts RenderOptions) (int, int, error) {
+	countScript := fmt.Sprintf(`document.querySelectorAll(%q).length`, opts.CountSelector)
+
+	var count int
+	if err := chromedp.Run(ctx, chromedp.Evaluate(countScript, &count)); err != nil {
+		return 0, 0, err
+	}
+
+	stable, scrolls := 0, 0
+	for scrolls < opts.MaxScrolls && stable < opts.StableRounds {
+		if opts.Depth > 0 && count >= opts.Depth {
+			break
+		}
+
+		prev := count
+		err := chromedp.Run(ctx,
+			chromedp.Evaluate(scrollScript, nil),
+			chromedp.Sleep(opts.ScrollPause),
+			chromedp.Evaluate(countScript, &count),
+		)
+		if err != nil {
+			return count, scrolls, err
+		}
+		scrolls++
+
+		if count > prev {
+			stable = 0
+		} else {
+			stable++
+		}
+	}
+	return count, scrolls, nil
+}
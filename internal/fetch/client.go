@@ -0,0 +1,93 @@
+// Package fetch retrieves pages over plain HTTP or through a headless browser.
+package fetch
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"math/rand/v2"
+	"net/http"
+	"time"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// UserAgent is sent with every plain HTTP request.
+const UserAgent = "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/91.0.4472.124 Safari/537.36"
+
+// NewClient returns the HTTP client used for plain page fetches.
+func NewClient() *http.Client {
+	transport := &http.Transport{
+		TLSClientConfig: &tls.Config{InsecureSkipVerify: true},
+	}
+
+	return &http.Client{
+		Timeout:   30 * time.Second,
+		Transport: transport,
+	}
+}
+
+// Get issues a GET request with browser-like headers.
+func Get(ctx context.Context, client *http.Client, url string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("User-Agent", UserAgent)
+	req.Header.Set("Accept", "text/html,application/xhtml+xml,application/xml;q=0.9,image/webp,*/*;q=0.8")
+	req.Header.Set("Accept-Language", "en-GB,en;q=0.5")
+
+	return client.Do(req)
+}
+
+// Page fetches url and parses the response body as HTML.
+func Page(url string) (*goquery.Document, error) {
+	resp, err := Get(context.Background(), NewClient(), url)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("status code error: %d %s", resp.StatusCode, resp.Status)
+	}
+
+	return goquery.NewDocumentFromReader(resp.Body)
+}
+
+// RandomDelay sleeps for two to three seconds so consecutive requests
+// don't hit the same host back to back.
+func RandomDelay() {
+	time.Sleep(2*time.Second + Jitter(time.Second))
+}
+
+// Backoff spaces out retries of a failed fetch: the first retry waits
+// Initial, each one after that twice as long as the last, up to Max, plus a
+// little jitter.
+type Backoff struct {
+	Retries int           // retries after the first attempt
+	Initial time.Duration // wait before the first retry
+	Max     time.Duration // longest wait between attempts
+}
+
+// Delay returns how long to wait before retry n, counting from 1.
+func (b Backoff) Delay(n int) time.Duration {
+	d := b.Initial
+	for i := 1; i < n && (b.Max <= 0 || d < b.Max); i++ {
+		d *= 2
+	}
+	if b.Max > 0 && d > b.Max {
+		d = b.Max
+	}
+	return d + Jitter(d/4)
+}
+
+// Jitter returns a random duration below max, so repeated work doesn't land
+// at exactly the same moment every time. It is safe for concurrent use.
+func Jitter(max time.Duration) time.Duration {
+	if max <= 0 {
+		return 0
+	}
+	return rand.N(max)
+}
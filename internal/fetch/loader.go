@@ -0,0 +1,48 @@
+package fetch
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"time"
+
+	"myproject/internal/metrics"
+	"myproject/internal/stage"
+)
+
+// Loader returns the HTML of a page.
+type Loader interface {
+	Load(ctx context.Context, url string) (string, error)
+}
+
+// HTTPLoader fetches pages with a plain GET, without running any scripts.
+type HTTPLoader struct {
+	Client *http.Client
+}
+
+// Load implements Loader. The whole request, body included, is timed as the
+// navigate stage.
+func (l HTTPLoader) Load(ctx context.Context, url string) (string, error) {
+	defer metrics.ObserveStage(metrics.StageNavigate, time.Now())
+
+	client := l.Client
+	if client == nil {
+		client = NewClient()
+	}
+
+	resp, err := Get(ctx, client, url)
+	if err != nil {
+		return "", stage.Wrap(stage.Network, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", stage.StatusError(resp.StatusCode, resp.Status)
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", stage.Wrap(stage.Network, err)
+	}
+	return string(body), nil
+}
@@ -0,0 +1,82 @@
+package history
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"myproject/internal/alert"
+	"myproject/internal/storage"
+)
+
+// PreviousRanks returns, for every app and chart, the latest rank recorded
+// before t. Ranks from charts that failed are skipped, so an app's previous
+// position survives a failed run.
+func (s *Store) PreviousRanks(t time.Time) ([]storage.Record, error) {
+	records, err := s.latestRanks(formatTime(t))
+	if err != nil {
+		return nil, fmt.Errorf("read previous ranks: %w", err)
+	}
+	return records, nil
+}
+
+// maxTime sorts after every stored time.
+const maxTime = "9999"
+
+// latestRanks returns the latest rank of every app and chart recorded before
+// the formatted time before, skipping failed charts.
+func (s *Store) latestRanks(before string) ([]storage.Record, error) {
+	rows, err := s.db.Query(`SELECT recorded_at, store, device, country, category, list, app_id, app_name, rank, status, depth
+		FROM (
+			SELECT *, row_number() OVER (
+				PARTITION BY app_id, store, device, country, category, list
+				ORDER BY recorded_at DESC, id DESC
+			) AS n
+			FROM app_ranks
+			WHERE recorded_at < ? AND status != ?
+		)
+		WHERE n = 1`, before, storage.StatusChartFailed)
+	if err != nil {
+		return nil, err
+	}
+	return scanRecords(rows)
+}
+
+// SaveAlert records an alert fired during a run.
+func (s *Store) SaveAlert(runID int64, a alert.Alert) error {
+	_, err := s.db.Exec(`INSERT INTO alerts
+		(run_id, fired_at, alert_key, rule, kind, app_name, app_id, store, device, country, category, list, from_rank, to_rank, other, message)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		runID, formatTime(a.At), a.Key(), a.Rule, a.Kind, a.App, a.AppID,
+		a.Chart.Store, a.Chart.Device, a.Chart.Country, a.Chart.Category, a.Chart.List,
+		nullInt(int64(a.From)), nullInt(int64(a.To)), a.Other, a.Message)
+	if err != nil {
+		return fmt.Errorf("save alert: %w", err)
+	}
+	return nil
+}
+
+// LastAlert returns the most recent alert fired for key, and whether there
+// was one.
+func (s *Store) LastAlert(key string) (alert.Alert, bool, error) {
+	var a alert.Alert
+	var firedAt string
+	var from, to sql.NullInt64
+	err := s.db.QueryRow(`SELECT fired_at, rule, kind, app_name, app_id, store, device, country, category, list, from_rank, to_rank, other, message
+		FROM alerts WHERE alert_key = ? ORDER BY fired_at DESC, id DESC LIMIT 1`, key).
+		Scan(&firedAt, &a.Rule, &a.Kind, &a.App, &a.AppID, &a.Chart.Store, &a.Chart.Device, &a.Chart.Country, &a.Chart.Category, &a.Chart.List,
+			&from, &to, &a.Other, &a.Message)
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		return alert.Alert{}, false, nil
+	case err != nil:
+		return alert.Alert{}, false, fmt.Errorf("look up alert: %w", err)
+	}
+
+	if a.At, err = ParseTime(firedAt); err != nil {
+		return alert.Alert{}, false, fmt.Errorf("look up alert: %w", err)
+	}
+	a.From, a.To = int(from.Int64), int(to.Int64)
+	return a, true, nil
+}
@@ -0,0 +1,308 @@
+// Package history keeps every run, chart snapshot and tracked-app rank in an
+// embedded SQLite database.
+package history
+
+import (
+	"database/sql"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	_ "modernc.org/sqlite"
+
+	"myproject/internal/chart"
+	"myproject/internal/stage"
+	"myproject/internal/storage"
+)
+
+// DefaultPath is where commands keep the database unless told otherwise.
+var DefaultPath = filepath.Join(storage.Dir, "history.db")
+
+// timeFormat is how timestamps are stored: UTC, fixed width, so they sort and
+// compare as text and SQLite's date functions understand them.
+const timeFormat = "2006-01-02 15:04:05"
+
+const schema = `
+CREATE TABLE IF NOT EXISTS runs (
+	id          INTEGER PRIMARY KEY,
+	command     TEXT NOT NULL,
+	started_at  TEXT NOT NULL,
+	finished_at TEXT
+);
+
+CREATE TABLE IF NOT EXISTS charts (
+	id         INTEGER PRIMARY KEY,
+	run_id     INTEGER NOT NULL REFERENCES runs(id),
+	source     TEXT NOT NULL,
+	store      TEXT NOT NULL,
+	country    TEXT NOT NULL,
+	category   TEXT NOT NULL,
+	list       TEXT NOT NULL,
+	fetched_at TEXT NOT NULL,
+	error      TEXT
+);
+
+CREATE TABLE IF NOT EXISTS chart_entries (
+	chart_id  INTEGER NOT NULL REFERENCES charts(id),
+	rank      INTEGER NOT NULL,
+	app_id    TEXT NOT NULL DEFAULT '',
+	name      TEXT NOT NULL,
+	developer TEXT NOT NULL DEFAULT '',
+	pricing   TEXT NOT NULL DEFAULT '',
+	PRIMARY KEY (chart_id, rank)
+);
+
+CREATE INDEX IF NOT EXISTS chart_entries_by_app ON chart_entries (app_id);
+
+CREATE TABLE IF NOT EXISTS app_ranks (
+	id          INTEGER PRIMARY KEY,
+	run_id      INTEGER NOT NULL REFERENCES runs(id),
+	chart_id    INTEGER REFERENCES charts(id),
+	recorded_at TEXT NOT NULL,
+	store       TEXT NOT NULL,
+	country     TEXT NOT NULL,
+	category    TEXT NOT NULL,
+	list        TEXT NOT NULL,
+	app_id      TEXT NOT NULL,
+	app_name    TEXT NOT NULL,
+	rank        INTEGER,
+	status      TEXT NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS app_ranks_by_time ON app_ranks (recorded_at);
+
+CREATE TABLE IF NOT EXISTS alerts (
+	id        INTEGER PRIMARY KEY,
+	run_id    INTEGER NOT NULL REFERENCES runs(id),
+	fired_at  TEXT NOT NULL,
+	alert_key TEXT NOT NULL,
+	rule      TEXT NOT NULL,
+	kind      TEXT NOT NULL,
+	app_name  TEXT NOT NULL,
+	store     TEXT NOT NULL,
+	device    TEXT NOT NULL,
+	country   TEXT NOT NULL,
+	category  TEXT NOT NULL,
+	list      TEXT NOT NULL,
+	from_rank INTEGER,
+	to_rank   INTEGER,
+	other     TEXT NOT NULL DEFAULT '',
+	message   TEXT NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS alerts_by_key ON alerts (alert_key, fired_at);
+`
+
+// migrations bring databases created from an older schema up to date.
+// migrations[i] moves user_version i to i+1; append new steps, never edit old
+// ones.
+var migrations = []string{
+	`ALTER TABLE charts ADD COLUMN depth INTEGER NOT NULL DEFAULT 0`,
+	`ALTER TABLE app_ranks ADD COLUMN depth INTEGER;
+	UPDATE app_ranks SET status = 'below-loaded-depth' WHERE status = 'not-found';`,
+	`ALTER TABLE chart_entries ADD COLUMN icon TEXT NOT NULL DEFAULT '';
+	ALTER TABLE chart_entries ADD COLUMN iap INTEGER NOT NULL DEFAULT 0;`,
+	`ALTER TABLE charts ADD COLUMN device TEXT NOT NULL DEFAULT '';
+	ALTER TABLE app_ranks ADD COLUMN device TEXT NOT NULL DEFAULT '';
+	UPDATE charts SET device = 'iphone' WHERE store = 'ios';
+	UPDATE app_ranks SET device = 'iphone' WHERE store = 'ios';`,
+	`UPDATE charts SET country = 'US' WHERE country = 'united-states';
+	UPDATE charts SET country = 'GB' WHERE country = 'united-kingdom';
+	UPDATE charts SET country = upper(country) WHERE length(country) = 2;
+	UPDATE app_ranks SET country = 'US' WHERE country = 'united-states';
+	UPDATE app_ranks SET country = 'GB' WHERE country = 'united-kingdom';
+	UPDATE app_ranks SET country = upper(country) WHERE length(country) = 2;`,
+	`ALTER TABLE charts ADD COLUMN attempts INTEGER NOT NULL DEFAULT 1;
+	ALTER TABLE charts ADD COLUMN error_stage TEXT;`,
+	// App-page ranks used to be saved without a list; see appstore.BadgeList
+	`UPDATE app_ranks SET list = 'free' WHERE list = '' AND chart_id IS NULL;`,
+	// Alerts keep the app ID their key is built from
+	`ALTER TABLE alerts ADD COLUMN app_id TEXT NOT NULL DEFAULT '';`,
+	// Indexes that take in the device column and follow the lookups in
+	// query.go and alerts.go
+	`DROP INDEX IF EXISTS charts_by_key;
+	DROP INDEX IF EXISTS app_ranks_by_app;
+	CREATE INDEX charts_by_key ON charts (store, device, country, category, list, fetched_at);
+	CREATE INDEX charts_by_run ON charts (run_id);
+	CREATE INDEX app_ranks_by_app ON app_ranks (app_id, store, device, country, category, list, recorded_at);
+	CREATE INDEX app_ranks_by_name ON app_ranks (app_name, store, device, country, category, list, recorded_at);
+	CREATE INDEX alerts_by_run ON alerts (run_id);`,
+	// Rank series are looked up by app ID, which app_ranks_by_app serves
+	`DROP INDEX IF EXISTS app_ranks_by_name;`,
+}
+
+// Store is an open history database.
+type Store struct {
+	db *sql.DB
+}
+
+// Open opens or creates the database at path and makes sure the schema exists.
+func Open(path string) (*Store, error) {
+	if err := os.MkdirAll(filepath.Dir(path), os.ModePerm); err != nil {
+		return nil, fmt.Errorf("create history directory: %w", err)
+	}
+
+	db, err := sql.Open("sqlite", path+"?_pragma=busy_timeout(5000)&_pragma=journal_mode(WAL)&_pragma=foreign_keys(1)")
+	if err != nil {
+		return nil, err
+	}
+	// SQLite allows one writer at a time; a single connection avoids
+	// "database is locked" between our own goroutines
+	db.SetMaxOpenConns(1)
+
+	if _, err := db.Exec(schema); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("create schema: %w", err)
+	}
+	if err := migrate(db); err != nil {
+		db.Close()
+		return nil, err
+	}
+	return &Store{db: db}, nil
+}
+
+// migrate applies the migrations the database hasn't seen yet.
+func migrate(db *sql.DB) error {
+	var version int
+	if err := db.QueryRow(`PRAGMA user_version`).Scan(&version); err != nil {
+		return fmt.Errorf("read schema version: %w", err)
+	}
+	for ; version < len(migrations); version++ {
+		tx, err := db.Begin()
+		if err != nil {
+			return err
+		}
+		if _, err := tx.Exec(migrations[version]); err != nil {
+			tx.Rollback()
+			return fmt.Errorf("migrate schema to version %d: %w", version+1, err)
+		}
+		// PRAGMA doesn't take bound parameters
+		if _, err := tx.Exec(fmt.Sprintf(`PRAGMA user_version = %d`, version+1)); err != nil {
+			tx.Rollback()
+			return fmt.Errorf("migrate schema to version %d: %w", version+1, err)
+		}
+		if err := tx.Commit(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Close closes the database.
+func (s *Store) Close() error {
+	return s.db.Close()
+}
+
+// StartRun records the start of a command and returns the run ID.
+func (s *Store) StartRun(command string, at time.Time) (int64, error) {
+	res, err := s.db.Exec(`INSERT INTO runs (command, started_at) VALUES (?, ?)`, command, formatTime(at))
+	if err != nil {
+		return 0, fmt.Errorf("start run: %w", err)
+	}
+	return res.LastInsertId()
+}
+
+// FinishRun marks a run as finished.
+func (s *Store) FinishRun(runID int64, at time.Time) error {
+	_, err := s.db.Exec(`UPDATE runs SET finished_at = ? WHERE id = ?`, formatTime(at), runID)
+	if err != nil {
+		return fmt.Errorf("finish run: %w", err)
+	}
+	return nil
+}
+
+// SaveChart stores a chart snapshot with all of its entries and returns the
+// chart ID. attempts counts the fetches it took, retries included.
+func (s *Store) SaveChart(runID int64, c *chart.Chart, attempts int) (int64, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	res, err := tx.Exec(`INSERT INTO charts (run_id, source, store, device, country, category, list, fetched_at, depth, attempts)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		runID, c.Source, c.Request.Store, c.Request.Device, c.Request.Country, c.Request.Category, c.Request.List, formatTime(c.FetchedAt), c.Depth, attempts)
+	if err != nil {
+		return 0, fmt.Errorf("save chart: %w", err)
+	}
+	chartID, err := res.LastInsertId()
+	if err != nil {
+		return 0, err
+	}
+
+	stmt, err := tx.Prepare(`INSERT OR IGNORE INTO chart_entries (chart_id, rank, app_id, name, developer, pricing, icon, iap)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)`)
+	if err != nil {
+		return 0, err
+	}
+	defer stmt.Close()
+
+	for _, e := range c.Entries {
+		if _, err := stmt.Exec(chartID, e.Rank, e.AppID, e.Name, e.Developer, e.Pricing, e.Icon, e.IAP); err != nil {
+			return 0, fmt.Errorf("save chart entry %d: %w", e.Rank, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+	return chartID, nil
+}
+
+// SaveChartError records a chart that couldn't be fetched after attempts
+// tries, along with the stage that failed, and returns its ID.
+func (s *Store) SaveChartError(runID int64, source string, req chart.Request, at time.Time, attempts int, chartErr error) (int64, error) {
+	res, err := s.db.Exec(`INSERT INTO charts (run_id, source, store, device, country, category, list, fetched_at, attempts, error, error_stage)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		runID, source, req.Store, req.Device, req.Country, req.Category, req.List, formatTime(at), attempts, chartErr.Error(), nullString(string(stage.Of(chartErr))))
+	if err != nil {
+		return 0, fmt.Errorf("save chart error: %w", err)
+	}
+	return res.LastInsertId()
+}
+
+// SaveRanks stores tracked-app ranks. chartID is 0 when the ranks didn't come
+// from a stored chart.
+func (s *Store) SaveRanks(runID, chartID int64, records []storage.Record) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.Prepare(`INSERT INTO app_ranks
+		(run_id, chart_id, recorded_at, store, device, country, category, list, app_id, app_name, rank, status, depth)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	for _, r := range records {
+		_, err := stmt.Exec(runID, nullInt(chartID), formatTime(r.Time),
+			r.Store, r.Device, r.Country, r.Category, r.List, r.AppID, r.AppName, nullInt(int64(r.Rank)), r.Status, nullInt(int64(r.Depth)))
+		if err != nil {
+			return fmt.Errorf("save rank for %s: %w", r.AppName, err)
+		}
+	}
+	return tx.Commit()
+}
+
+func formatTime(t time.Time) string {
+	return t.UTC().Format(timeFormat)
+}
+
+// ParseTime reads a timestamp as stored in the database.
+func ParseTime(s string) (time.Time, error) {
+	return time.Parse(timeFormat, s)
+}
+
+func nullInt(v int64) sql.NullInt64 {
+	return sql.NullInt64{Int64: v, Valid: v != 0}
+}
+
+func nullString(s string) sql.NullString {
+	return sql.NullString{String: s, Valid: s != ""}
+}
@@ -0,0 +1,66 @@
+package history
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"myproject/internal/chart"
+	"myproject/internal/storage"
+)
+
+// ImportChart stores a chart snapshot unless one from the same source, chart
+// and time is already present. It returns the chart ID either way and whether
+// the snapshot was new.
+func (s *Store) ImportChart(runID int64, c *chart.Chart) (int64, bool, error) {
+	var id int64
+	err := s.db.QueryRow(`SELECT id FROM charts
+		WHERE source = ? AND store = ? AND device = ? AND country = ? AND category = ? AND list = ? AND fetched_at = ?`,
+		c.Source, c.Request.Store, c.Request.Device, c.Request.Country, c.Request.Category, c.Request.List, formatTime(c.FetchedAt)).Scan(&id)
+	switch {
+	case err == nil:
+		return id, false, nil
+	case !errors.Is(err, sql.ErrNoRows):
+		return 0, false, fmt.Errorf("look up chart: %w", err)
+	}
+
+	id, err = s.SaveChart(runID, c, 1)
+	return id, err == nil, err
+}
+
+// ImportRanks stores the records that aren't already present for the same
+// time, chart and app, and returns how many were new.
+func (s *Store) ImportRanks(runID, chartID int64, records []storage.Record) (int, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.Prepare(`INSERT INTO app_ranks
+		(run_id, chart_id, recorded_at, store, device, country, category, list, app_id, app_name, rank, status, depth)
+		SELECT ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?
+		WHERE NOT EXISTS (SELECT 1 FROM app_ranks
+			WHERE recorded_at = ? AND store = ? AND device = ? AND country = ? AND category = ? AND list = ? AND app_id = ?)`)
+	if err != nil {
+		return 0, err
+	}
+	defer stmt.Close()
+
+	inserted := 0
+	for _, r := range records {
+		at := formatTime(r.Time)
+		res, err := stmt.Exec(runID, nullInt(chartID), at,
+			r.Store, r.Device, r.Country, r.Category, r.List, r.AppID, r.AppName, nullInt(int64(r.Rank)), r.Status, nullInt(int64(r.Depth)),
+			at, r.Store, r.Device, r.Country, r.Category, r.List, r.AppID)
+		if err != nil {
+			return 0, fmt.Errorf("import rank for %s: %w", r.AppName, err)
+		}
+		n, err := res.RowsAffected()
+		if err != nil {
+			return 0, err
+		}
+		inserted += int(n)
+	}
+	return inserted, tx.Commit()
+}
@@ -0,0 +1,276 @@
+package history
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"myproject/internal/chart"
+	"myproject/internal/storage"
+)
+
+// ErrNotFound is returned by lookups that match nothing.
+var ErrNotFound = errors.New("not found")
+
+// Bucket sizes for RankBuckets. Buckets follow UTC, like the stored times.
+const (
+	BucketHour = "hour"
+	BucketDay  = "day"
+)
+
+var bucketFormats = map[string]string{
+	BucketHour: "%Y-%m-%d %H:00:00",
+	BucketDay:  "%Y-%m-%d 00:00:00",
+}
+
+// SeriesQuery selects one app's ranks in one chart over [From, To).
+type SeriesQuery struct {
+	App      string // the ID the app's ranks are recorded under; see match.RecordID
+	Chart    chart.Request
+	From, To time.Time
+	Limit    int
+	Offset   int
+}
+
+// Bucket aggregates the ranks recorded in one hour or day. Min, Max and Avg
+// only count the times the app was ranked; Samples counts every record.
+type Bucket struct {
+	Start   time.Time
+	Min     int
+	Max     int
+	Avg     float64
+	Ranked  int
+	Samples int
+}
+
+// RankSeries returns the matching records, oldest first.
+func (s *Store) RankSeries(q SeriesQuery) ([]storage.Record, error) {
+	rows, err := s.db.Query(`SELECT recorded_at, store, device, country, category, list, app_id, app_name, rank, status, depth
+		FROM app_ranks
+		WHERE app_id = ? AND store = ? AND device = ? AND country = ? AND category = ? AND list = ?
+			AND recorded_at >= ? AND recorded_at < ?
+		ORDER BY recorded_at, id
+		LIMIT ? OFFSET ?`,
+		q.App, q.Chart.Store, q.Chart.Device, q.Chart.Country, q.Chart.Category, q.Chart.List,
+		formatTime(q.From), formatTime(q.To), q.Limit, q.Offset)
+	if err != nil {
+		return nil, fmt.Errorf("read rank series: %w", err)
+	}
+	records, err := scanRecords(rows)
+	if err != nil {
+		return nil, fmt.Errorf("read rank series: %w", err)
+	}
+	return records, nil
+}
+
+// RankBuckets aggregates the matching records into hourly or daily buckets,
+// oldest first. Empty buckets are left out.
+func (s *Store) RankBuckets(q SeriesQuery, size string) ([]Bucket, error) {
+	format, ok := bucketFormats[size]
+	if !ok {
+		return nil, fmt.Errorf("unknown bucket size %q", size)
+	}
+
+	rows, err := s.db.Query(`SELECT strftime(?, recorded_at) AS bucket,
+			MIN(rank), MAX(rank), AVG(rank), COUNT(rank), COUNT(*)
+		FROM app_ranks
+		WHERE app_id = ? AND store = ? AND device = ? AND country = ? AND category = ? AND list = ?
+			AND recorded_at >= ? AND recorded_at < ?
+		GROUP BY bucket
+		ORDER BY bucket
+		LIMIT ? OFFSET ?`,
+		format, q.App, q.Chart.Store, q.Chart.Device, q.Chart.Country, q.Chart.Category, q.Chart.List,
+		formatTime(q.From), formatTime(q.To), q.Limit, q.Offset)
+	if err != nil {
+		return nil, fmt.Errorf("read rank buckets: %w", err)
+	}
+	defer rows.Close()
+
+	var buckets []Bucket
+	for rows.Next() {
+		var b Bucket
+		var start string
+		var min, max sql.NullInt64
+		var avg sql.NullFloat64
+		if err := rows.Scan(&start, &min, &max, &avg, &b.Ranked, &b.Samples); err != nil {
+			return nil, fmt.Errorf("read rank buckets: %w", err)
+		}
+		if b.Start, err = ParseTime(start); err != nil {
+			return nil, fmt.Errorf("read rank buckets: %w", err)
+		}
+		b.Min, b.Max, b.Avg = int(min.Int64), int(max.Int64), avg.Float64
+		buckets = append(buckets, b)
+	}
+	return buckets, rows.Err()
+}
+
+// LatestChart returns the most recent chart snapshot for req that loaded,
+// with all of its entries, and its ID.
+func (s *Store) LatestChart(req chart.Request) (*chart.Chart, int64, error) {
+	var id int64
+	var fetchedAt string
+	c := &chart.Chart{Request: req}
+	err := s.db.QueryRow(`SELECT id, source, fetched_at, depth FROM charts
+		WHERE store = ? AND device = ? AND country = ? AND category = ? AND list = ? AND error IS NULL
+		ORDER BY fetched_at DESC, id DESC LIMIT 1`,
+		req.Store, req.Device, req.Country, req.Category, req.List).Scan(&id, &c.Source, &fetchedAt, &c.Depth)
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		return nil, 0, ErrNotFound
+	case err != nil:
+		return nil, 0, fmt.Errorf("look up chart: %w", err)
+	}
+	if c.FetchedAt, err = ParseTime(fetchedAt); err != nil {
+		return nil, 0, fmt.Errorf("look up chart: %w", err)
+	}
+
+	rows, err := s.db.Query(`SELECT rank, app_id, name, developer, pricing, icon, iap
+		FROM chart_entries WHERE chart_id = ? ORDER BY rank`, id)
+	if err != nil {
+		return nil, 0, fmt.Errorf("read chart entries: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var e chart.Entry
+		if err := rows.Scan(&e.Rank, &e.AppID, &e.Name, &e.Developer, &e.Pricing, &e.Icon, &e.IAP); err != nil {
+			return nil, 0, fmt.Errorf("read chart entries: %w", err)
+		}
+		c.Entries = append(c.Entries, e)
+	}
+	return c, id, rows.Err()
+}
+
+// Run is a command invocation and how its charts went.
+type Run struct {
+	ID         int64
+	Command    string
+	StartedAt  time.Time
+	FinishedAt time.Time // zero while the run is going, or if it never finished
+	Charts     int       // charts fetched or attempted
+	Failed     int       // charts that couldn't be fetched
+	Retried    int       // charts that needed more than one attempt
+	Alerts     int       // alerts fired
+}
+
+// Run statuses.
+const (
+	RunRunning = "running" // not finished, or stopped without finishing
+	RunOK      = "ok"      // every chart loaded
+	RunPartial = "partial" // some charts failed
+	RunFailed  = "failed"  // every chart failed
+)
+
+// Status sums the run up as one of the Run statuses.
+func (r Run) Status() string {
+	switch {
+	case r.FinishedAt.IsZero():
+		return RunRunning
+	case r.Charts > 0 && r.Failed == r.Charts:
+		return RunFailed
+	case r.Failed > 0:
+		return RunPartial
+	}
+	return RunOK
+}
+
+const runQuery = `SELECT r.id, r.command, r.started_at, r.finished_at,
+		(SELECT COUNT(*) FROM charts c WHERE c.run_id = r.id),
+		(SELECT COUNT(*) FROM charts c WHERE c.run_id = r.id AND c.error IS NOT NULL),
+		(SELECT COUNT(*) FROM charts c WHERE c.run_id = r.id AND c.attempts > 1),
+		(SELECT COUNT(*) FROM alerts a WHERE a.run_id = r.id)
+	FROM runs r`
+
+// Runs returns runs newest first.
+func (s *Store) Runs(limit, offset int) ([]Run, error) {
+	rows, err := s.db.Query(runQuery+` ORDER BY r.id DESC LIMIT ? OFFSET ?`, limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("read runs: %w", err)
+	}
+	defer rows.Close()
+
+	var runs []Run
+	for rows.Next() {
+		r, err := scanRun(rows)
+		if err != nil {
+			return nil, fmt.Errorf("read runs: %w", err)
+		}
+		runs = append(runs, r)
+	}
+	return runs, rows.Err()
+}
+
+// Run returns one run.
+func (s *Store) Run(id int64) (Run, error) {
+	r, err := scanRun(s.db.QueryRow(runQuery+` WHERE r.id = ?`, id))
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		return Run{}, ErrNotFound
+	case err != nil:
+		return Run{}, fmt.Errorf("read run: %w", err)
+	}
+	return r, nil
+}
+
+func scanRun(row interface{ Scan(...any) error }) (Run, error) {
+	var r Run
+	var started string
+	var finished sql.NullString
+	if err := row.Scan(&r.ID, &r.Command, &started, &finished, &r.Charts, &r.Failed, &r.Retried, &r.Alerts); err != nil {
+		return Run{}, err
+	}
+	var err error
+	if r.StartedAt, err = ParseTime(started); err != nil {
+		return Run{}, err
+	}
+	if finished.Valid {
+		if r.FinishedAt, err = ParseTime(finished.String); err != nil {
+			return Run{}, err
+		}
+	}
+	return r, nil
+}
+
+// LatestRanks returns, for every app and chart, the latest rank recorded.
+// Like PreviousRanks, ranks from charts that failed are skipped.
+func (s *Store) LatestRanks() ([]storage.Record, error) {
+	records, err := s.latestRanks(maxTime)
+	if err != nil {
+		return nil, fmt.Errorf("read latest ranks: %w", err)
+	}
+	return records, nil
+}
+
+// LatestCharts returns the most recent snapshot of every chart that loaded,
+// without entries.
+func (s *Store) LatestCharts() ([]chart.Chart, error) {
+	rows, err := s.db.Query(`SELECT source, store, device, country, category, list, fetched_at, depth
+		FROM (
+			SELECT *, row_number() OVER (
+				PARTITION BY store, device, country, category, list
+				ORDER BY fetched_at DESC, id DESC
+			) AS n
+			FROM charts
+			WHERE error IS NULL
+		)
+		WHERE n = 1
+		ORDER BY store, device, country, category, list`)
+	if err != nil {
+		return nil, fmt.Errorf("read latest charts: %w", err)
+	}
+	defer rows.Close()
+
+	var charts []chart.Chart
+	for rows.Next() {
+		var c chart.Chart
+		var fetchedAt string
+		if err := rows.Scan(&c.Source, &c.Request.Store, &c.Request.Device, &c.Request.Country, &c.Request.Category, &c.Request.List, &fetchedAt, &c.Depth); err != nil {
+			return nil, fmt.Errorf("read latest charts: %w", err)
+		}
+		if c.FetchedAt, err = ParseTime(fetchedAt); err != nil {
+			return nil, fmt.Errorf("read latest charts: %w", err)
+		}
+		charts = append(charts, c)
+	}
+	return charts, rows.Err()
+}
@@ -0,0 +1,51 @@
+package history
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"myproject/internal/storage"
+)
+
+// Ranks returns the ranks recorded from start up to but not including end,
+// oldest first.
+func (s *Store) Ranks(start, end time.Time) ([]storage.Record, error) {
+	rows, err := s.db.Query(`SELECT recorded_at, store, device, country, category, list, app_id, app_name, rank, status, depth
+		FROM app_ranks
+		WHERE recorded_at >= ? AND recorded_at < ?
+		ORDER BY recorded_at, id`, formatTime(start), formatTime(end))
+	if err != nil {
+		return nil, fmt.Errorf("read ranks: %w", err)
+	}
+	records, err := scanRecords(rows)
+	if err != nil {
+		return nil, fmt.Errorf("read ranks: %w", err)
+	}
+	return records, nil
+}
+
+// scanRecords reads app_ranks rows selected as recorded_at, store, device,
+// country, category, list, app_id, app_name, rank, status, depth, and closes
+// them.
+func scanRecords(rows *sql.Rows) ([]storage.Record, error) {
+	defer rows.Close()
+
+	var records []storage.Record
+	for rows.Next() {
+		var r storage.Record
+		var recordedAt string
+		var rank, depth sql.NullInt64
+		err := rows.Scan(&recordedAt, &r.Store, &r.Device, &r.Country, &r.Category, &r.List,
+			&r.AppID, &r.AppName, &rank, &r.Status, &depth)
+		if err != nil {
+			return nil, err
+		}
+		if r.Time, err = ParseTime(recordedAt); err != nil {
+			return nil, err
+		}
+		r.Rank, r.Depth = int(rank.Int64), int(depth.Int64)
+		records = append(records, r)
+	}
+	return records, rows.Err()
+}
@@ -0,0 +1,283 @@
+// Package importer loads the CSV files earlier versions of the scraper wrote
+// into results/ into the history database.
+package importer
+
+import (
+	"encoding/csv"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"myproject/internal/catalog"
+	"myproject/internal/chart"
+	"myproject/internal/history"
+	"myproject/internal/storage"
+	"myproject/internal/watchlist"
+)
+
+// Layouts the importer recognises.
+const (
+	LayoutChart   = "chart"    // Rank,Name,Pricing,Developer full-chart dumps
+	LayoutTracked = "tracked"  // Timestamp,CoinbaseRank,... or Timestamp,US_CoinbaseRank,...
+	LayoutWide    = "wide"     // the multi-header apps_ranks.csv spreadsheet
+	LayoutAppPage = "app-page" // Name,Rank,Timestamp with "#32 in Finance" ranks
+	LayoutRecords = "records"  // the long-format ranks.csv
+)
+
+// Every legacy file came from the free finance charts.
+const (
+	legacyCategory = "finance"
+	legacyList     = "free"
+	// appstore.go only ever scraped the US chart and left the country out of
+	// its file names
+	legacyCountry = "US"
+)
+
+var (
+	// apps_<ts>.csv, apps_<country>_<ts>.csv or, as the chart command now
+	// writes, apps_<country>_<store>[-<device>]_<category>_<list>_<ts>.csv
+	fileRegex = regexp.MustCompile(`^apps_(?:([a-z-]+)_)?(?:([a-z]+)(?:-([a-z]+))?_([a-z-]+)_([a-z]+)_)?(\d{4}-\d{2}-\d{2}_\d{2}-\d{2}-\d{2})\.csv$`)
+	rankRegex = regexp.MustCompile(`^#?\s*(\d+)(?:\s+in\s+.+)?$`)
+)
+
+var timeFormats = []string{
+	"2006-01-02 15:04:05",
+	"2006-01-02 15:04",
+	"02/01/2006 15:04:05",
+	"02/01/2006 15:04",
+	"2006-01-02_15-04-05",
+}
+
+// Importer loads legacy files into a history store as part of one run.
+type Importer struct {
+	Store     *history.Store
+	RunID     int64
+	Watchlist *watchlist.Watchlist
+	Location  *time.Location // zone the legacy timestamps were written in
+}
+
+// maxProblems caps how many bad rows a Result describes.
+const maxProblems = 5
+
+// Result summarises one imported file.
+type Result struct {
+	Path     string
+	Layout   string
+	Charts   int      // new chart snapshots
+	Ranks    int      // new tracked-app ranks
+	Skipped  int      // rows or files left out because they held no data
+	Bad      int      // rows left out because they couldn't be read
+	Problems []string // why the first few bad rows couldn't be read
+	Err      error    // why the file couldn't be imported, if it couldn't
+}
+
+// bad counts rows[i] as unreadable.
+func (res *Result) bad(i int, err error) {
+	res.Bad++
+	if len(res.Problems) < maxProblems {
+		res.Problems = append(res.Problems, fmt.Sprintf("row %d: %v", i+1, err))
+	}
+}
+
+// ImportDir imports every CSV file in dir in name order. A file that fails
+// doesn't stop the others: its Result carries the error, and the returned
+// error counts the failures. Files whose layout isn't recognised are reported
+// with an empty Layout.
+func (im *Importer) ImportDir(dir string) ([]Result, error) {
+	paths, err := filepath.Glob(filepath.Join(dir, "*.csv"))
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(paths)
+
+	var results []Result
+	failed := 0
+	for _, path := range paths {
+		res, err := im.ImportFile(path)
+		if err != nil {
+			res.Path, res.Err = path, err
+			failed++
+		}
+		results = append(results, res)
+	}
+	if failed > 0 {
+		return results, fmt.Errorf("%d of %d files failed to import", failed, len(paths))
+	}
+	return results, nil
+}
+
+// ImportFile detects the layout of path and imports it.
+func (im *Importer) ImportFile(path string) (Result, error) {
+	rows, err := readRows(path)
+	if err != nil {
+		return Result{}, err
+	}
+
+	res := Result{Path: path, Layout: Detect(rows)}
+	switch res.Layout {
+	case LayoutChart:
+		err = im.importChart(path, rows, &res)
+	case LayoutTracked:
+		err = im.importTracked(path, rows, &res)
+	case LayoutWide:
+		err = im.importWide(rows, &res)
+	case LayoutAppPage:
+		err = im.importAppPage(rows, &res)
+	case LayoutRecords:
+		err = im.importRecords(path, &res)
+	}
+	return res, err
+}
+
+// Detect names the layout of a file from its header rows, or returns "".
+func Detect(rows [][]string) string {
+	if len(rows) == 0 {
+		return ""
+	}
+	h := rows[0]
+	switch {
+	case len(h) >= 2 && h[0] == "Rank" && h[1] == "Name":
+		return LayoutChart
+	case len(h) >= 2 && h[0] == "Date" && h[1] == "Time":
+		return LayoutWide
+	case len(h) >= 3 && h[0] == "Name" && h[1] == "Rank" && h[2] == "Timestamp":
+		return LayoutAppPage
+	case len(h) >= 2 && h[0] == "Timestamp" && strings.HasSuffix(h[1], "Rank"):
+		return LayoutTracked
+	case len(h) >= 9 && h[0] == "timestamp" && h[1] == "store":
+		return LayoutRecords
+	}
+	return ""
+}
+
+func readRows(path string) ([][]string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	// Legacy files have ragged rows and the wide layout starts with a blank
+	// line, which the csv reader skips for us
+	reader := csv.NewReader(file)
+	reader.FieldsPerRecord = -1
+	reader.LazyQuotes = true
+	return reader.ReadAll()
+}
+
+// fileInfo reads the chart and timestamp from names like
+// apps_united-kingdom_2024-10-21_15-03-55.csv. Parts a legacy name leaves
+// out are filled in with the legacy defaults.
+func (im *Importer) fileInfo(path string) (req chart.Request, at time.Time, ok bool) {
+	m := fileRegex.FindStringSubmatch(filepath.Base(path))
+	if m == nil {
+		return chart.Request{}, time.Time{}, false
+	}
+	at, err := time.ParseInLocation("2006-01-02_15-04-05", m[6], im.Location)
+	if err != nil {
+		return chart.Request{}, time.Time{}, false
+	}
+
+	req = chart.Request{
+		Store:    chart.StoreIOS,
+		Device:   chart.DefaultDevice(chart.StoreIOS),
+		Country:  catalog.CountryCode(m[1]),
+		Category: legacyCategory,
+		List:     legacyList,
+	}
+	if req.Country == "" {
+		req.Country = legacyCountry
+	}
+	if m[2] != "" {
+		req.Store, req.Device, req.Category, req.List = m[2], m[3], m[4], m[5]
+	}
+	return req, at, true
+}
+
+// rowTime reads a data row's timestamp, from either its first cell or, when
+// the date and time were written separately, its first two. It returns how
+// many cells the timestamp took.
+func (im *Importer) rowTime(row []string) (time.Time, int, error) {
+	at, err := im.parseTime(row[0])
+	if err == nil {
+		return at, 1, nil
+	}
+	if len(row) > 1 {
+		if at, err := im.parseTime(row[0] + " " + row[1]); err == nil {
+			return at, 2, nil
+		}
+	}
+	return time.Time{}, 0, err
+}
+
+func (im *Importer) parseTime(s string) (time.Time, error) {
+	s = strings.TrimSpace(s)
+	for _, layout := range timeFormats {
+		if t, err := time.ParseInLocation(layout, s, im.Location); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("unrecognised timestamp %q", s)
+}
+
+// parseRank reads "32", "#32" or "#32 in Finance"; blank cells give 0.
+func parseRank(s string) (int, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return 0, nil
+	}
+	m := rankRegex.FindStringSubmatch(s)
+	if m == nil {
+		return 0, fmt.Errorf("unrecognised rank %q", s)
+	}
+	return strconv.Atoi(m[1])
+}
+
+// app finds the watchlist app a legacy column or row refers to by name, key
+// or alias. Apps since removed from the watchlist get a stand-in so their
+// history isn't lost.
+func (im *Importer) app(name string) watchlist.App {
+	name = strings.TrimSpace(name)
+	for _, app := range im.Watchlist.Apps {
+		if strings.EqualFold(app.Name, name) || strings.EqualFold(app.Key, name) {
+			return app
+		}
+		for _, alias := range app.Aliases {
+			if strings.EqualFold(alias, name) {
+				return app
+			}
+		}
+	}
+	return watchlist.App{Name: name, Key: strings.ReplaceAll(name, " ", "")}
+}
+
+// record builds a tracked rank, deriving the status from the cell: a blank
+// cell is a failed chart when every app in the same chart is blank, and an app
+// below the part of the chart that loaded otherwise.
+func record(at time.Time, store, country string, app watchlist.App, appID string, rank int, chartBlank bool) storage.Record {
+	r := storage.Record{
+		Time:     at,
+		Store:    store,
+		Device:   chart.DefaultDevice(store),
+		Country:  country,
+		Category: legacyCategory,
+		List:     legacyList,
+		AppID:    appID,
+		AppName:  app.Name,
+		Rank:     rank,
+	}
+	switch {
+	case rank > 0:
+		r.Status = storage.StatusRanked
+	case chartBlank:
+		r.Status = storage.StatusChartFailed
+	default:
+		r.Status = storage.StatusBelowDepth
+	}
+	return r
+}
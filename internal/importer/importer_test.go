@@ -0,0 +1,147 @@
+package importer
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"myproject/internal/chart"
+	"myproject/internal/history"
+	"myproject/internal/match"
+	"myproject/internal/watchlist"
+)
+
+// The legacy files the scraper left in the repo's results folder, and the
+// watchlist that names their apps
+const (
+	resultsDir    = "../../results"
+	watchlistPath = "../../watchlist.yaml"
+)
+
+func newImporter(t *testing.T) *Importer {
+	t.Helper()
+	wl, err := watchlist.Load(watchlistPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	hist, err := history.Open(filepath.Join(t.TempDir(), "history.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { hist.Close() })
+
+	runID, err := hist.StartRun("import", time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &Importer{Store: hist, RunID: runID, Watchlist: wl, Location: time.UTC}
+}
+
+func TestImportDir(t *testing.T) {
+	im := newImporter(t)
+
+	results, err := im.ImportDir(resultsDir)
+	if err != nil {
+		t.Fatalf("ImportDir: %v", err)
+	}
+
+	byName := make(map[string]Result)
+	for _, res := range results {
+		if res.Layout == "" {
+			t.Errorf("%s: layout not recognised", res.Path)
+		}
+		byName[filepath.Base(res.Path)] = res
+	}
+	for name, want := range map[string]Result{
+		"apps_ranks.csv":  {Layout: LayoutWide, Ranks: 24},
+		"apps_ranks1.csv": {Layout: LayoutTracked, Ranks: 78, Bad: 2},
+		"apps_united-kingdom_2024-10-21_15-03-55.csv": {Layout: LayoutChart, Charts: 1, Ranks: 3},
+		"apps_united-states_2024-10-28_22-12-49.csv":  {Layout: LayoutChart, Charts: 1, Ranks: 3},
+		"apps_united-kingdom_2024-10-28_22-13-04.csv": {Layout: LayoutChart, Skipped: 1},
+	} {
+		got := byName[name]
+		if got.Layout != want.Layout || got.Charts != want.Charts || got.Ranks != want.Ranks ||
+			got.Skipped != want.Skipped || got.Bad != want.Bad {
+			t.Errorf("%s: got %s layout, %d charts, %d ranks, %d skipped, %d bad; want %s, %d, %d, %d, %d",
+				name, got.Layout, got.Charts, got.Ranks, got.Skipped, got.Bad,
+				want.Layout, want.Charts, want.Ranks, want.Skipped, want.Bad)
+		}
+	}
+	if problems := byName["apps_ranks1.csv"].Problems; len(problems) != 2 {
+		t.Errorf("apps_ranks1.csv problems = %q", problems)
+	}
+
+	// Importing again adds nothing
+	again, err := im.ImportDir(resultsDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, res := range again {
+		if res.Charts != 0 || res.Ranks != 0 {
+			t.Errorf("%s re-imported %d charts and %d ranks", res.Path, res.Charts, res.Ranks)
+		}
+	}
+}
+
+// apps_ranks1.csv starts in the tracked layout and goes on with rows in the
+// wide layout, with the date and time in separate columns.
+func TestImportMixedLayouts(t *testing.T) {
+	im := newImporter(t)
+
+	res, err := im.ImportFile(filepath.Join(resultsDir, "apps_ranks1.csv"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Bad != 2 {
+		t.Errorf("bad rows = %d, want 2: %q", res.Bad, res.Problems)
+	}
+
+	var coinbase watchlist.App
+	for _, app := range im.Watchlist.Apps {
+		if app.Name == "Coinbase" {
+			coinbase = app
+		}
+	}
+
+	at := time.Date(2024, 11, 6, 12, 5, 43, 0, time.UTC)
+	tests := []struct {
+		req  chart.Request
+		want int
+	}{
+		{chart.Request{Store: chart.StoreIOS, Device: chart.DeviceIPhone, Country: "US", Category: "finance", List: "free"}, 30},
+		{chart.Request{Store: chart.StoreIOS, Device: chart.DeviceIPhone, Country: "GB", Category: "finance", List: "free"}, 80},
+		{chart.Request{Store: chart.StorePlay, Country: "GB", Category: "finance", List: "free"}, 68},
+	}
+	for _, tt := range tests {
+		records, err := im.Store.RankSeries(history.SeriesQuery{
+			App: match.RecordID(coinbase, tt.req.Store), Chart: tt.req, From: at, To: at.Add(time.Second), Limit: 10,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(records) != 1 || records[0].Rank != tt.want {
+			t.Errorf("%s: got %+v, want rank %d", tt.req, records, tt.want)
+		}
+	}
+}
+
+func TestParseRank(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    int
+		wantErr bool
+	}{
+		{"32", 32, false},
+		{"#32", 32, false},
+		{"#32 in Finance", 32, false},
+		{"", 0, false},
+		{"702024-11-06", 0, true},
+		{"n/a", 0, true},
+	}
+	for _, tt := range tests {
+		got, err := parseRank(tt.in)
+		if got != tt.want || (err != nil) != tt.wantErr {
+			t.Errorf("parseRank(%q) = %d, %v", tt.in, got, err)
+		}
+	}
+}
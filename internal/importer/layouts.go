@@ -0,0 +1,346 @@
+package importer
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"myproject/internal/appstore"
+	"myproject/internal/catalog"
+	"myproject/internal/chart"
+	"myproject/internal/match"
+	"myproject/internal/storage"
+	"myproject/internal/watchlist"
+)
+
+var (
+	trackedColumnRegex = regexp.MustCompile(`^(?:([A-Z]{2})_)?(.+)Rank$`)
+	wideHeadingRegex   = regexp.MustCompile(`^(.+?) - (.+?)(?: \(([^,]+), ([^,]+)(?:, ([^,]+))?\))?$`)
+)
+
+var storeHeadings = map[string]string{
+	"iOS App Store":     chart.StoreIOS,
+	"Google Play Store": chart.StorePlay,
+}
+
+// importChart loads a full-chart dump. The file name carries the chart and
+// time; tracked ranks are derived by matching the watchlist against it.
+func (im *Importer) importChart(path string, rows [][]string, res *Result) error {
+	req, at, ok := im.fileInfo(path)
+	if !ok {
+		return fmt.Errorf("can't read country and time from the file name")
+	}
+
+	c := &chart.Chart{
+		Request:   req,
+		Source:    "appfigures-" + req.Store,
+		FetchedAt: at,
+	}
+	for _, row := range rows[1:] {
+		rank, err := parseRank(row[0])
+		if err != nil || rank == 0 || len(row) < 2 {
+			res.Skipped++
+			continue
+		}
+		e := chart.Entry{Rank: rank, Name: strings.TrimSpace(row[1])}
+		if len(row) > 2 {
+			e.Pricing = row[2]
+		}
+		if len(row) > 3 {
+			e.Developer = row[3]
+		}
+		if len(row) > 5 {
+			e.IAP, _ = strconv.ParseBool(row[4])
+			e.Icon = row[5]
+		}
+		c.Entries = append(c.Entries, e)
+	}
+	if len(c.Entries) == 0 {
+		// A header with no rows is what a failed scrape left behind
+		res.Skipped++
+		return nil
+	}
+	c.Depth = chart.LoadedDepth(c.Entries)
+
+	chartID, isNew, err := im.Store.ImportChart(im.RunID, c)
+	if err != nil {
+		return err
+	}
+	if isNew {
+		res.Charts++
+	}
+
+	matched := match.Chart(c.Request.Store, c.Entries, im.Watchlist.Apps)
+	ranks := matched.Ranks()
+	var records []storage.Record
+	for _, app := range im.Watchlist.Apps {
+		r := record(at, req.Store, req.Country, app, match.RecordID(app, req.Store), ranks[app.Key], false)
+		if r.Rank == 0 && matched.IsAmbiguous(app) {
+			r.Status = storage.StatusNotMatched
+		}
+		r.Device, r.Category, r.List = req.Device, req.Category, req.List
+		r.Depth = c.Depth
+		records = append(records, r)
+	}
+
+	n, err := im.Store.ImportRanks(im.RunID, chartID, records)
+	res.Ranks += n
+	return err
+}
+
+// column is one rank column of the tracked or wide layout.
+type column struct {
+	index int
+	req   chart.Request
+	app   watchlist.App
+}
+
+// legacyWideHeader is the chart and app rows of the wide layout that the
+// scraper appended, without its header, to apps_ranks1.csv in November 2024.
+var legacyWideHeader = [2][]string{
+	{"", "", "United States - iOS App Store", "", "", "United Kingdom - iOS App Store", "", "",
+		"United States - Google Play Store", "", "", "United Kingdom - Google Play Store", "", ""},
+	{"", "", "Coinbase", "OKX", "Trust Wallet", "Coinbase", "OKX", "Trust Wallet",
+		"Coinbase", "OKX", "Trust Wallet", "Coinbase", "OKX", "Trust Wallet"},
+}
+
+// importTracked loads Timestamp,CoinbaseRank,... files, where the country is
+// in the file name, and Timestamp,US_CoinbaseRank,... files, where it's in
+// the column prefix. Rows with the date and time in separate columns were
+// appended in the wide layout and are read as legacyWideHeader describes.
+func (im *Importer) importTracked(path string, rows [][]string, res *Result) error {
+	fileReq, _, _ := im.fileInfo(path)
+
+	var cols []column
+	for i, h := range rows[0][1:] {
+		m := trackedColumnRegex.FindStringSubmatch(strings.TrimSpace(h))
+		if m == nil {
+			continue
+		}
+		country := fileReq.Country
+		if m[1] != "" {
+			country = ""
+			if c, ok := catalog.LookupCountry(m[1]); ok {
+				country = c.Code
+			}
+		}
+		if country == "" {
+			return fmt.Errorf("can't tell which country column %q is for", h)
+		}
+		req := chart.Request{
+			Store:    chart.StoreIOS,
+			Device:   chart.DefaultDevice(chart.StoreIOS),
+			Country:  country,
+			Category: legacyCategory,
+			List:     legacyList,
+		}
+		cols = append(cols, column{index: i + 1, req: req, app: im.app(m[2])})
+	}
+	wideCols, err := im.wideColumns(legacyWideHeader[0], legacyWideHeader[1])
+	if err != nil {
+		return err
+	}
+
+	return im.importRows(rows, 1, res, func(timeCells int) []column {
+		if timeCells == 2 {
+			return wideCols
+		}
+		return cols
+	})
+}
+
+// importWide loads the spreadsheet layout: Date/Time, chart headings such as
+// "United States - iOS App Store" spanning their apps, then app names. Rows
+// with a single timestamp column have every rank one column to the left.
+func (im *Importer) importWide(rows [][]string, res *Result) error {
+	if len(rows) < 3 {
+		return fmt.Errorf("wide layout needs three header rows")
+	}
+	cols, err := im.wideColumns(rows[1], rows[2])
+	if err != nil {
+		return err
+	}
+
+	shifted := make([]column, len(cols))
+	for i, col := range cols {
+		col.index--
+		shifted[i] = col
+	}
+	return im.importRows(rows, 3, res, func(timeCells int) []column {
+		if timeCells == 1 {
+			return shifted
+		}
+		return cols
+	})
+}
+
+// wideColumns reads the rank columns from the wide layout's chart and app
+// rows.
+func (im *Importer) wideColumns(headings, apps []string) ([]column, error) {
+	var cols []column
+	var req chart.Request
+	for i := 2; i < len(apps); i++ {
+		if i < len(headings) && strings.TrimSpace(headings[i]) != "" {
+			var err error
+			if req, err = parseHeading(headings[i]); err != nil {
+				return nil, err
+			}
+		}
+		if strings.TrimSpace(apps[i]) == "" || req.Store == "" {
+			continue
+		}
+		cols = append(cols, column{index: i, req: req, app: im.app(apps[i])})
+	}
+	return cols, nil
+}
+
+// importRows loads the data rows from rows[first:]. Each row's timestamp
+// takes one or two cells, and colsFor returns the rank columns for rows with
+// that many. Blank rows are skipped and rows that can't be read are counted
+// as bad.
+func (im *Importer) importRows(rows [][]string, first int, res *Result, colsFor func(timeCells int) []column) error {
+	var records []storage.Record
+	for i := first; i < len(rows); i++ {
+		row := rows[i]
+		if len(row) == 0 || strings.TrimSpace(row[0]) == "" {
+			res.Skipped++
+			continue
+		}
+		rowRecords, err := im.rowRecords(row, colsFor)
+		if err != nil {
+			res.bad(i, err)
+			continue
+		}
+		records = append(records, rowRecords...)
+	}
+
+	n, err := im.Store.ImportRanks(im.RunID, 0, records)
+	res.Ranks += n
+	return err
+}
+
+// rowRecords builds a record per column from one data row. A blank cell is a
+// failed chart when every app in the same chart is blank.
+func (im *Importer) rowRecords(row []string, colsFor func(timeCells int) []column) ([]storage.Record, error) {
+	at, timeCells, err := im.rowTime(row)
+	if err != nil {
+		return nil, err
+	}
+	cols := colsFor(timeCells)
+
+	ranks := make([]int, len(cols))
+	charted := make(map[chart.Request]bool)
+	for i, col := range cols {
+		if col.index < len(row) {
+			if ranks[i], err = parseRank(row[col.index]); err != nil {
+				return nil, err
+			}
+		}
+		if ranks[i] > 0 {
+			charted[col.req] = true
+		}
+	}
+	// Anything past the last column means cells went missing or rows ran
+	// together
+	last := timeCells - 1
+	if len(cols) > 0 {
+		last = cols[len(cols)-1].index
+	}
+	for _, cell := range row[last+1:] {
+		if strings.TrimSpace(cell) != "" {
+			return nil, fmt.Errorf("%d cells, expected at most %d", len(row), last+1)
+		}
+	}
+
+	records := make([]storage.Record, 0, len(cols))
+	for i, col := range cols {
+		r := record(at, col.req.Store, col.req.Country, col.app, match.RecordID(col.app, col.req.Store), ranks[i], !charted[col.req])
+		r.Device, r.Category, r.List = col.req.Device, col.req.Category, col.req.List
+		records = append(records, r)
+	}
+	return records, nil
+}
+
+// parseHeading reads "United States - iOS App Store", optionally followed by
+// " (finance, free)" or " (finance, free, ipad)" as written by the export
+// command.
+func parseHeading(h string) (chart.Request, error) {
+	m := wideHeadingRegex.FindStringSubmatch(strings.TrimSpace(h))
+	if m == nil {
+		return chart.Request{}, fmt.Errorf("unrecognised chart heading %q", h)
+	}
+	store, ok := storeHeadings[m[2]]
+	if !ok {
+		return chart.Request{}, fmt.Errorf("unrecognised store in heading %q", h)
+	}
+
+	req := chart.Request{
+		Store:    store,
+		Device:   chart.DefaultDevice(store),
+		Country:  catalog.CountryCode(m[1]),
+		Category: legacyCategory,
+		List:     legacyList,
+	}
+	if m[3] != "" {
+		req.Category, req.List = m[3], m[4]
+	}
+	if m[5] != "" {
+		req.Device = m[5]
+	}
+	return req, nil
+}
+
+// importAppPage loads the App Store product-page log, whose ranks read
+// "#32 in Finance". Like the app-page command, it is keyed on the US
+// storefront the page was scraped from, the category is stored as a
+// catalogue key and the list is appstore.BadgeList.
+func (im *Importer) importAppPage(rows [][]string, res *Result) error {
+	var records []storage.Record
+	for i := 1; i < len(rows); i++ {
+		row := rows[i]
+		if len(row) < 3 {
+			res.Skipped++
+			continue
+		}
+		at, err := im.parseTime(row[2])
+		if err != nil {
+			res.bad(i, err)
+			continue
+		}
+		rank, category, ok := appstore.AppPage{CategoryRank: strings.TrimSpace(row[1])}.Rank()
+		if !ok {
+			res.Skipped++
+			continue
+		}
+
+		app := im.app(row[0])
+		records = append(records, storage.Record{
+			Time:     at,
+			Store:    chart.StoreIOS,
+			Device:   chart.DeviceIPhone,
+			Country:  legacyCountry,
+			Category: catalog.CategoryKey(category),
+			List:     appstore.BadgeList,
+			AppID:    match.RecordID(app, chart.StoreIOS),
+			AppName:  app.Name,
+			Rank:     rank,
+			Status:   storage.StatusRanked,
+		})
+	}
+
+	n, err := im.Store.ImportRanks(im.RunID, 0, records)
+	res.Ranks += n
+	return err
+}
+
+// importRecords loads a long-format ranks.csv as it is.
+func (im *Importer) importRecords(path string, res *Result) error {
+	records, err := storage.ReadRecords(path)
+	if err != nil {
+		return err
+	}
+	n, err := im.Store.ImportRanks(im.RunID, 0, records)
+	res.Ranks += n
+	return err
+}
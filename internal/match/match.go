@@ -0,0 +1,168 @@
+// Package match finds watchlist apps in a chart, by store identity first and
+// by name alias only when a chart entry carries no identity.
+package match
+
+import (
+	"strings"
+
+	"myproject/internal/chart"
+	"myproject/internal/watchlist"
+)
+
+// How an entry was matched to an app.
+const (
+	ByID    = "id"
+	ByAlias = "alias"
+)
+
+// Match ties a watchlist app to its chart entry.
+type Match struct {
+	App   watchlist.App
+	Entry chart.Entry
+	By    string
+}
+
+// Ambiguity is an app whose aliases fit more than one entry, or an entry whose
+// name fits more than one app. Ambiguous apps are left unmatched.
+type Ambiguity struct {
+	App        watchlist.App
+	Candidates []chart.Entry
+}
+
+// Result is the outcome of matching one chart.
+type Result struct {
+	Matches   []Match
+	Ambiguous []Ambiguity
+}
+
+// Ranks returns the rank of each matched app keyed by App.Key.
+func (r Result) Ranks() map[string]int {
+	ranks := make(map[string]int, len(r.Matches))
+	for _, m := range r.Matches {
+		ranks[m.App.Key] = m.Entry.Rank
+	}
+	return ranks
+}
+
+// IsAmbiguous reports whether app was left unmatched because more than one
+// entry could have been it.
+func (r Result) IsAmbiguous(app watchlist.App) bool {
+	for _, a := range r.Ambiguous {
+		if a.App.Key == app.Key {
+			return true
+		}
+	}
+	return false
+}
+
+// StoreID returns the identity app has in store, if the watchlist gives one.
+func StoreID(app watchlist.App, store string) string {
+	switch store {
+	case chart.StoreIOS:
+		return app.IOSID
+	case chart.StorePlay:
+		return app.PlayPackage
+	}
+	return ""
+}
+
+// RecordID is the ID an app's ranks in store are recorded under: its store
+// identity when the watchlist gives one, its key otherwise. Unlike the name,
+// it survives the app being renamed.
+func RecordID(app watchlist.App, store string) string {
+	if id := StoreID(app, store); id != "" {
+		return id
+	}
+	return app.Key
+}
+
+// Chart matches apps against the entries of a chart from store.
+func Chart(store string, entries []chart.Entry, apps []watchlist.App) Result {
+	var res Result
+
+	// Identity matches are unambiguous and take their entries off the table
+	claimed := make(map[int]bool)
+	var pending []watchlist.App
+	for _, app := range apps {
+		id := StoreID(app, store)
+		i := indexByID(entries, id)
+		if i < 0 {
+			pending = append(pending, app)
+			continue
+		}
+		claimed[i] = true
+		res.Matches = append(res.Matches, Match{App: app, Entry: entries[i], By: ByID})
+	}
+
+	// Fall back to aliases, but never for an entry that carries an ID: if it
+	// were ours the ID would have matched above
+	candidates := make(map[string][]int)
+	claims := make(map[int]int)
+	for _, app := range pending {
+		for i, e := range entries {
+			if claimed[i] || e.AppID != "" && StoreID(app, store) != "" {
+				continue
+			}
+			if aliasMatches(app.Aliases, e) {
+				candidates[app.Key] = append(candidates[app.Key], i)
+				claims[i]++
+			}
+		}
+	}
+
+	for _, app := range pending {
+		idx := candidates[app.Key]
+		switch {
+		case len(idx) == 0:
+			continue
+		case len(idx) == 1 && claims[idx[0]] == 1:
+			res.Matches = append(res.Matches, Match{App: app, Entry: entries[idx[0]], By: ByAlias})
+		default:
+			amb := Ambiguity{App: app}
+			for _, i := range idx {
+				amb.Candidates = append(amb.Candidates, entries[i])
+			}
+			res.Ambiguous = append(res.Ambiguous, amb)
+		}
+	}
+
+	return res
+}
+
+func indexByID(entries []chart.Entry, id string) int {
+	if id == "" {
+		return -1
+	}
+	for i, e := range entries {
+		if strings.EqualFold(e.AppID, id) {
+			return i
+		}
+	}
+	return -1
+}
+
+// aliasMatches reports whether the entry's name or title is one of the
+// aliases, or one of them followed by a subtitle ("Coinbase: Buy Bitcoin").
+func aliasMatches(aliases []string, e chart.Entry) bool {
+	for _, alias := range aliases {
+		alias = normalize(alias)
+		if alias == "" {
+			continue
+		}
+		for _, name := range []string{e.Name, e.Title} {
+			name = normalize(name)
+			if name == alias {
+				return true
+			}
+			rest, ok := strings.CutPrefix(name, alias)
+			if ok && (strings.HasPrefix(rest, ":") || strings.HasPrefix(rest, " -") || strings.HasPrefix(rest, " –")) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func normalize(s string) string {
+	return strings.ToLower(strings.Join(strings.Fields(s), " "))
+}
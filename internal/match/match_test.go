@@ -0,0 +1,118 @@
+package match
+
+import (
+	"testing"
+
+	"myproject/internal/chart"
+	"myproject/internal/watchlist"
+)
+
+var (
+	coinbase = watchlist.App{Name: "Coinbase", Key: "Coinbase", IOSID: "886427730", Aliases: []string{"Coinbase"}}
+	trust    = watchlist.App{Name: "Trust Wallet", Key: "TrustWallet", Aliases: []string{"Trust Wallet", "Trust"}}
+)
+
+func TestChart(t *testing.T) {
+	tests := []struct {
+		name      string
+		store     string
+		entries   []chart.Entry
+		apps      []watchlist.App
+		want      map[string]Match // by app key; only Entry.Rank and By are compared
+		ambiguous []string         // app keys
+	}{
+		{
+			name:  "ID match wins over alias",
+			store: chart.StoreIOS,
+			entries: []chart.Entry{
+				{Rank: 2, Name: "Coinbase"},
+				{Rank: 5, AppID: "886427730", Name: "Coinbase: Buy Bitcoin & Ether"},
+			},
+			apps: []watchlist.App{coinbase},
+			want: map[string]Match{"Coinbase": {Entry: chart.Entry{Rank: 5}, By: ByID}},
+		},
+		{
+			name:  "alias with a subtitle",
+			store: chart.StorePlay,
+			entries: []chart.Entry{
+				{Rank: 1, Name: "Cash App"},
+				{Rank: 3, Name: "Coinbase: Buy Bitcoin & Ether"},
+			},
+			apps: []watchlist.App{coinbase},
+			want: map[string]Match{"Coinbase": {Entry: chart.Entry{Rank: 3}, By: ByAlias}},
+		},
+		{
+			name:  "a bank named Trust isn't Trust Wallet",
+			store: chart.StoreIOS,
+			entries: []chart.Entry{
+				{Rank: 4, Name: "Trust Bank"},
+				{Rank: 9, Name: "Trustly"},
+				{Rank: 12, Name: "Trust: Crypto & Bitcoin Wallet"},
+			},
+			apps: []watchlist.App{trust},
+			want: map[string]Match{"TrustWallet": {Entry: chart.Entry{Rank: 12}, By: ByAlias}},
+		},
+		{
+			name:  "an entry with an ID isn't matched by alias for an app with one",
+			store: chart.StoreIOS,
+			entries: []chart.Entry{
+				{Rank: 2, AppID: "1", Name: "Coinbase: Wallet"},
+			},
+			apps: []watchlist.App{coinbase},
+			want: map[string]Match{},
+		},
+		{
+			name:  "entry claimed by two apps",
+			store: chart.StoreIOS,
+			entries: []chart.Entry{
+				{Rank: 7, Name: "Trust: Crypto & Bitcoin Wallet"},
+			},
+			apps: []watchlist.App{
+				trust,
+				{Name: "Trust Crypto", Key: "TrustCrypto", Aliases: []string{"Trust: Crypto & Bitcoin Wallet"}},
+			},
+			want:      map[string]Match{},
+			ambiguous: []string{"TrustWallet", "TrustCrypto"},
+		},
+		{
+			name:  "app fitting two entries",
+			store: chart.StoreIOS,
+			entries: []chart.Entry{
+				{Rank: 7, Name: "Trust: Crypto & Bitcoin Wallet"},
+				{Rank: 30, Name: "Trust Wallet - Lite"},
+			},
+			apps:      []watchlist.App{trust},
+			want:      map[string]Match{},
+			ambiguous: []string{"TrustWallet"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res := Chart(tt.store, tt.entries, tt.apps)
+
+			if len(res.Matches) != len(tt.want) {
+				t.Errorf("got %d matches, want %d: %+v", len(res.Matches), len(tt.want), res.Matches)
+			}
+			for _, m := range res.Matches {
+				want, ok := tt.want[m.App.Key]
+				if !ok || m.Entry.Rank != want.Entry.Rank || m.By != want.By {
+					t.Errorf("%s matched #%d by %s, want %+v", m.App.Key, m.Entry.Rank, m.By, want)
+				}
+			}
+
+			if len(res.Ambiguous) != len(tt.ambiguous) {
+				t.Errorf("got %d ambiguous apps, want %d: %+v", len(res.Ambiguous), len(tt.ambiguous), res.Ambiguous)
+			}
+			for _, key := range tt.ambiguous {
+				app := watchlist.App{Key: key}
+				if !res.IsAmbiguous(app) {
+					t.Errorf("%s isn't reported ambiguous", key)
+				}
+				if _, ok := res.Ranks()[key]; ok {
+					t.Errorf("ambiguous %s was given a rank", key)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,70 @@
+// Package historymetrics reports the latest ranks and chart depths in the
+// history database as Prometheus gauges. It is kept apart from package
+// metrics so the scrape code that records metrics doesn't depend on the
+// database.
+package historymetrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+
+	"myproject/internal/history"
+	"myproject/internal/storage"
+)
+
+var (
+	chartLabels = []string{"store", "device", "country", "category", "list"}
+
+	// Apps are labelled with the ID their ranks are recorded under, which
+	// unlike the name survives a rename
+	rankDesc = prometheus.NewDesc("appcheck_rank",
+		"Latest rank of a tracked app in a chart. Missing while the app isn't ranked.",
+		append([]string{"app_id"}, chartLabels...), nil)
+	depthDesc = prometheus.NewDesc("appcheck_chart_depth",
+		"Last rank the latest snapshot of a chart loaded to.",
+		chartLabels, nil)
+	collectErrorsDesc = prometheus.NewDesc("appcheck_history_collect_errors",
+		"1 when the history database couldn't be read for this collection.",
+		nil, nil)
+)
+
+// Collector reports the latest ranks and chart depths from the history
+// database whenever metrics are collected.
+type Collector struct {
+	Hist *history.Store
+}
+
+// Describe implements prometheus.Collector.
+func (c Collector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- rankDesc
+	ch <- depthDesc
+	ch <- collectErrorsDesc
+}
+
+// Collect implements prometheus.Collector.
+func (c Collector) Collect(ch chan<- prometheus.Metric) {
+	failed := 0.0
+
+	records, err := c.Hist.LatestRanks()
+	if err != nil {
+		failed = 1
+	}
+	for _, r := range records {
+		if r.Status != storage.StatusRanked {
+			continue
+		}
+		ch <- prometheus.MustNewConstMetric(rankDesc, prometheus.GaugeValue, float64(r.Rank),
+			r.AppID, r.Store, r.Device, r.Country, r.Category, r.List)
+	}
+
+	charts, err := c.Hist.LatestCharts()
+	if err != nil {
+		failed = 1
+	}
+	for _, snap := range charts {
+		req := snap.Request
+		ch <- prometheus.MustNewConstMetric(depthDesc, prometheus.GaugeValue, float64(snap.Depth),
+			req.Store, req.Device, req.Country, req.Category, req.List)
+	}
+
+	ch <- prometheus.MustNewConstMetric(collectErrorsDesc, prometheus.GaugeValue, failed)
+}
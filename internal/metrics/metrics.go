@@ -0,0 +1,90 @@
+// Package metrics exports scrape health in the Prometheus format. Scrape
+// metrics are counted in-process, so they only move in commands that scrape,
+// such as serve; package historymetrics adds the latest ranks and chart
+// depths from the history database.
+package metrics
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// Scrape stages timed by StageDuration.
+const (
+	StageNavigate  = "navigate"   // loading the page, up to the chart rows showing
+	StageScroll    = "scroll"     // scrolling until enough rows loaded
+	StageOuterHTML = "outer-html" // extracting the rendered HTML
+	StageParse     = "parse"      // reading the chart out of the HTML
+)
+
+var (
+	// StageDuration times each stage of fetching a chart.
+	StageDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "appcheck_scrape_stage_duration_seconds",
+		Help:    "Time spent in each stage of fetching a chart.",
+		Buckets: []float64{0.05, 0.25, 1, 2.5, 5, 10, 20, 40, 80, 160},
+	}, []string{"stage"})
+
+	// Retries counts chart fetches that were retried.
+	Retries = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "appcheck_scrape_retries_total",
+		Help: "Chart fetches retried after a network error or timeout.",
+	}, []string{"store"})
+
+	// ChartFailures counts charts that failed after any retries, by the
+	// stage that failed.
+	ChartFailures = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "appcheck_chart_failures_total",
+		Help: "Charts that couldn't be fetched, by failing stage.",
+	}, []string{"store", "stage"})
+
+	// SelectorMisses counts pages whose chart rows couldn't be found: in
+	// the browser while waiting for them, or in the HTML by every parser
+	// profile.
+	SelectorMisses = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "appcheck_selector_misses_total",
+		Help: "Pages where the chart row selectors matched nothing.",
+	}, []string{"where"})
+
+	// StorageErrors counts failed writes of results.
+	StorageErrors = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "appcheck_storage_errors_total",
+		Help: "Failed writes of results, by destination.",
+	}, []string{"target"})
+)
+
+// Storage error targets.
+const (
+	TargetCSV     = "csv"
+	TargetHistory = "history"
+)
+
+// Registry holds the scrape metrics and the Go runtime and process metrics.
+var Registry = prometheus.NewRegistry()
+
+func init() {
+	Registry.MustRegister(
+		StageDuration, Retries, ChartFailures, SelectorMisses, StorageErrors,
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+	)
+}
+
+// ObserveStage records that stage took since start.
+func ObserveStage(stage string, start time.Time) {
+	StageDuration.WithLabelValues(stage).Observe(time.Since(start).Seconds())
+}
+
+// Handler serves Registry and the extra collectors, such as a
+// historymetrics.Collector, in the Prometheus text format.
+func Handler(extra ...prometheus.Collector) http.Handler {
+	reg := prometheus.NewRegistry()
+	for _, c := range extra {
+		reg.MustRegister(c)
+	}
+	return promhttp.HandlerFor(prometheus.Gatherers{Registry, reg}, promhttp.HandlerOpts{})
+}
@@ -0,0 +1,113 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"myproject/internal/fetch"
+	"myproject/internal/stage"
+	"myproject/internal/storage"
+)
+
+// DeadLetterPath is where undeliverable messages are kept unless told
+// otherwise.
+var DeadLetterPath = filepath.Join(storage.Dir, "undelivered.jsonl")
+
+// Sink is somewhere messages can be sent.
+type Sink interface {
+	Name() string
+	Wants(event string) bool
+	Send(ctx context.Context, m Message) error
+}
+
+// Dispatcher sends each message to every sink that wants it. Transient
+// failures are retried with backoff; a message a sink still hasn't taken is
+// appended to the dead-letter file as a JSON line.
+type Dispatcher struct {
+	Sinks      []Sink
+	Backoff    fetch.Backoff
+	DeadLetter string
+
+	mu sync.Mutex // serialises dead-letter writes
+}
+
+// deadLetter is one line of the dead-letter file.
+type deadLetter struct {
+	At       time.Time `json:"at"`
+	Sink     string    `json:"sink"`
+	Attempts int       `json:"attempts"`
+	Error    string    `json:"error"`
+	Message  Message   `json:"message"`
+}
+
+// Send delivers m and returns the errors of the sinks that didn't take it.
+func (d *Dispatcher) Send(ctx context.Context, m Message) error {
+	var errs []error
+	for _, sink := range d.Sinks {
+		if !sink.Wants(m.Event) {
+			continue
+		}
+		attempts, err := d.deliver(ctx, sink, m)
+		if err == nil {
+			continue
+		}
+		errs = append(errs, fmt.Errorf("%s: %w", sink.Name(), err))
+
+		if d.DeadLetter == "" {
+			continue
+		}
+		dl := deadLetter{At: time.Now(), Sink: sink.Name(), Attempts: attempts, Error: err.Error(), Message: m}
+		if err := d.save(dl); err != nil {
+			log.Printf("Error saving undelivered %s message: %v", sink.Name(), err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// deliver sends m to sink, retrying transient failures, and returns the
+// number of attempts made.
+func (d *Dispatcher) deliver(ctx context.Context, sink Sink, m Message) (int, error) {
+	for attempt := 1; ; attempt++ {
+		err := sink.Send(ctx, m)
+		if err == nil || !stage.Transient(err) || attempt > d.Backoff.Retries {
+			return attempt, err
+		}
+
+		wait := d.Backoff.Delay(attempt)
+		log.Printf("Error sending to %s, retrying in %s: %v", sink.Name(), wait.Round(time.Millisecond), err)
+		select {
+		case <-time.After(wait):
+		case <-ctx.Done():
+			return attempt, err
+		}
+	}
+}
+
+func (d *Dispatcher) save(dl deadLetter) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	line, err := json.Marshal(dl)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(d.DeadLetter), os.ModePerm); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(d.DeadLetter, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(append(line, '\n')); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
@@ -0,0 +1,132 @@
+package notify
+
+import (
+	"bytes"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net"
+	"net/smtp"
+	"net/textproto"
+	"strconv"
+	"strings"
+	"time"
+
+	"myproject/internal/watchlist"
+)
+
+// Mailer sends email through an SMTP server.
+type Mailer struct {
+	Config    watchlist.Email
+	Password  string
+	TLSConfig *tls.Config // for STARTTLS; by default the server's certificate is checked against Config.Host
+}
+
+// Mail sends one email with plain-text and HTML versions of the body to
+// every recipient.
+func (m *Mailer) Mail(subject, text, html string) error {
+	msg, err := m.message(subject, text, html)
+	if err != nil {
+		return err
+	}
+
+	addr := net.JoinHostPort(m.Config.Host, strconv.Itoa(m.Config.Port))
+	conn, err := net.DialTimeout("tcp", addr, 30*time.Second)
+	if err != nil {
+		return fmt.Errorf("connect to %s: %w", addr, err)
+	}
+	c, err := smtp.NewClient(conn, m.Config.Host)
+	if err != nil {
+		conn.Close()
+		return fmt.Errorf("connect to %s: %w", addr, err)
+	}
+	defer c.Close()
+
+	if m.Config.TLS == watchlist.EmailStartTLS {
+		if ok, _ := c.Extension("STARTTLS"); !ok {
+			return fmt.Errorf("%s doesn't offer STARTTLS", addr)
+		}
+		cfg := m.TLSConfig
+		if cfg == nil {
+			cfg = &tls.Config{ServerName: m.Config.Host}
+		}
+		if err := c.StartTLS(cfg); err != nil {
+			return fmt.Errorf("starttls: %w", err)
+		}
+	}
+
+	if m.Config.Username != "" {
+		if err := c.Auth(smtp.PlainAuth("", m.Config.Username, m.Password, m.Config.Host)); err != nil {
+			return fmt.Errorf("authenticate: %w", err)
+		}
+	}
+
+	if err := c.Mail(m.Config.From); err != nil {
+		return fmt.Errorf("mail from %s: %w", m.Config.From, err)
+	}
+	for _, to := range m.Config.To {
+		if err := c.Rcpt(to); err != nil {
+			return fmt.Errorf("rcpt to %s: %w", to, err)
+		}
+	}
+
+	w, err := c.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(msg); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	return c.Quit()
+}
+
+// message builds a multipart/alternative message, plain text first so
+// clients that can show HTML pick that instead.
+func (m *Mailer) message(subject, text, html string) ([]byte, error) {
+	for _, s := range append([]string{m.Config.From, subject}, m.Config.To...) {
+		if strings.ContainsAny(s, "\r\n") {
+			return nil, errors.New("email headers can't contain line breaks")
+		}
+	}
+
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+	for _, part := range []struct{ contentType, content string }{
+		{"text/plain; charset=utf-8", text},
+		{"text/html; charset=utf-8", html},
+	} {
+		pw, err := mw.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {part.contentType},
+			"Content-Transfer-Encoding": {"quoted-printable"},
+		})
+		if err != nil {
+			return nil, err
+		}
+		qw := quotedprintable.NewWriter(pw)
+		if _, err := qw.Write([]byte(part.content)); err != nil {
+			return nil, err
+		}
+		if err := qw.Close(); err != nil {
+			return nil, err
+		}
+	}
+	if err := mw.Close(); err != nil {
+		return nil, err
+	}
+
+	var msg bytes.Buffer
+	fmt.Fprintf(&msg, "From: %s\r\n", m.Config.From)
+	fmt.Fprintf(&msg, "To: %s\r\n", strings.Join(m.Config.To, ", "))
+	fmt.Fprintf(&msg, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", subject))
+	fmt.Fprintf(&msg, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	fmt.Fprintf(&msg, "MIME-Version: 1.0\r\n")
+	fmt.Fprintf(&msg, "Content-Type: multipart/alternative; boundary=%s\r\n\r\n", mw.Boundary())
+	msg.Write(body.Bytes())
+	return msg.Bytes(), nil
+}
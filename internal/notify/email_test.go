@@ -0,0 +1,197 @@
+package notify
+
+import (
+	"bufio"
+	"crypto/tls"
+	"encoding/base64"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/mail"
+	"net/textproto"
+	"strconv"
+	"strings"
+	"testing"
+
+	"myproject/internal/watchlist"
+)
+
+// smtpStandIn is just enough of an SMTP server to take one message: it
+// offers STARTTLS, accepts AUTH PLAIN once the connection is encrypted and
+// keeps what it was sent.
+type smtpStandIn struct {
+	ln   net.Listener
+	cert tls.Certificate
+	done chan struct{}
+
+	tls  bool
+	auth string // "identity\x00user\x00password"
+	from string
+	to   []string
+	data string
+}
+
+func newSMTPStandIn(t *testing.T, cert tls.Certificate) *smtpStandIn {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := &smtpStandIn{ln: ln, cert: cert, done: make(chan struct{})}
+	go s.serve(t)
+	return s
+}
+
+func (s *smtpStandIn) serve(t *testing.T) {
+	defer close(s.done)
+	conn, err := s.ln.Accept()
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+
+	tp := textproto.NewConn(conn)
+	tp.PrintfLine("220 stand-in ESMTP")
+	for {
+		line, err := tp.ReadLine()
+		if err != nil {
+			return
+		}
+		verb, arg, _ := strings.Cut(line, " ")
+		switch strings.ToUpper(verb) {
+		case "EHLO", "HELO":
+			if s.tls {
+				tp.PrintfLine("250-stand-in\r\n250 AUTH PLAIN")
+			} else {
+				tp.PrintfLine("250-stand-in\r\n250 STARTTLS")
+			}
+		case "STARTTLS":
+			tp.PrintfLine("220 go ahead")
+			tlsConn := tls.Server(conn, &tls.Config{Certificates: []tls.Certificate{s.cert}})
+			if err := tlsConn.Handshake(); err != nil {
+				t.Errorf("stand-in TLS handshake: %v", err)
+				return
+			}
+			conn, s.tls = tlsConn, true
+			tp = textproto.NewConn(conn)
+		case "AUTH":
+			creds, _ := base64.StdEncoding.DecodeString(strings.TrimPrefix(arg, "PLAIN "))
+			s.auth = string(creds)
+			tp.PrintfLine("235 ok")
+		case "MAIL":
+			s.from = arg
+			tp.PrintfLine("250 ok")
+		case "RCPT":
+			s.to = append(s.to, arg)
+			tp.PrintfLine("250 ok")
+		case "DATA":
+			tp.PrintfLine("354 go ahead")
+			data, err := tp.ReadDotBytes()
+			if err != nil {
+				return
+			}
+			s.data = string(data)
+			tp.PrintfLine("250 ok")
+		case "QUIT":
+			tp.PrintfLine("221 bye")
+			return
+		default:
+			tp.PrintfLine("502 unknown command")
+		}
+	}
+}
+
+func TestMailerStartTLS(t *testing.T) {
+	// Borrow httptest's certificate, which is valid for 127.0.0.1
+	https := httptest.NewTLSServer(http.NotFoundHandler())
+	defer https.Close()
+	roots := https.Client().Transport.(*http.Transport).TLSClientConfig.RootCAs
+
+	srv := newSMTPStandIn(t, https.TLS.Certificates[0])
+	defer srv.ln.Close()
+	host, port, _ := net.SplitHostPort(srv.ln.Addr().String())
+	portNum, _ := strconv.Atoi(port)
+
+	m := &Mailer{
+		Config: watchlist.Email{
+			Host: host, Port: portNum, TLS: watchlist.EmailStartTLS,
+			Username: "appcheck", From: "appcheck@example.com", To: []string{"growth@example.com", "ops@example.com"},
+		},
+		Password:  "hunter2",
+		TLSConfig: &tls.Config{RootCAs: roots, ServerName: host},
+	}
+	if err := m.Mail("appcheck ranks for Mon 28 Oct 2024", "Coinbase #12 ▲3", "<b>Coinbase</b> #12 ▲3"); err != nil {
+		t.Fatalf("Mail: %v", err)
+	}
+	<-srv.done
+
+	if !srv.tls {
+		t.Error("message was sent without STARTTLS")
+	}
+	if srv.auth != "\x00appcheck\x00hunter2" {
+		t.Errorf("auth = %q", srv.auth)
+	}
+	if srv.from != "FROM:<appcheck@example.com>" || len(srv.to) != 2 {
+		t.Errorf("envelope = %s to %v", srv.from, srv.to)
+	}
+
+	msg, err := mail.ReadMessage(strings.NewReader(srv.data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	subject, _ := new(mime.WordDecoder).DecodeHeader(msg.Header.Get("Subject"))
+	if subject != "appcheck ranks for Mon 28 Oct 2024" {
+		t.Errorf("subject = %q", subject)
+	}
+	mediaType, params, _ := mime.ParseMediaType(msg.Header.Get("Content-Type"))
+	if mediaType != "multipart/alternative" {
+		t.Fatalf("content type = %s", mediaType)
+	}
+
+	var parts []string
+	mr := multipart.NewReader(msg.Body, params["boundary"])
+	for {
+		p, err := mr.NextPart()
+		if err != nil {
+			break
+		}
+		body, _ := io.ReadAll(p) // multipart decodes quoted-printable
+		parts = append(parts, p.Header.Get("Content-Type")+": "+string(body))
+	}
+	want := []string{"text/plain; charset=utf-8: Coinbase #12 ▲3", "text/html; charset=utf-8: <b>Coinbase</b> #12 ▲3"}
+	if strings.Join(parts, "\n") != strings.Join(want, "\n") {
+		t.Errorf("parts =\n%s\nwant\n%s", strings.Join(parts, "\n"), strings.Join(want, "\n"))
+	}
+}
+
+func TestMailerRequiresStartTLS(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		w := bufio.NewWriter(conn)
+		r := bufio.NewReader(conn)
+		w.WriteString("220 plain ESMTP\r\n")
+		w.Flush()
+		r.ReadString('\n')
+		w.WriteString("250 plain\r\n")
+		w.Flush()
+		r.ReadString('\n')
+	}()
+
+	host, port, _ := net.SplitHostPort(ln.Addr().String())
+	portNum, _ := strconv.Atoi(port)
+	m := &Mailer{Config: watchlist.Email{Host: host, Port: portNum, TLS: watchlist.EmailStartTLS, From: "a@example.com", To: []string{"b@example.com"}}}
+	if err := m.Mail("subject", "text", "html"); err == nil || !strings.Contains(err.Error(), "STARTTLS") {
+		t.Errorf("Mail error = %v, want a missing STARTTLS error", err)
+	}
+}
@@ -0,0 +1,81 @@
+// Package notify sends alerts and run summaries to webhooks, retrying
+// failed deliveries and keeping the ones that never get through, and mails
+// the daily digest.
+package notify
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"myproject/internal/alert"
+	"myproject/internal/watchlist"
+)
+
+// Message is one notification: the alerts a run raised, or how the run went.
+type Message struct {
+	Event   string        `json:"event"` // watchlist.EventAlerts or watchlist.EventRuns
+	Command string        `json:"command"`
+	At      time.Time     `json:"at"`
+	Alerts  []alert.Alert `json:"alerts,omitempty"`
+	Summary *Summary      `json:"summary,omitempty"`
+}
+
+// Summary is how each chart of a run went. Charts are written as
+// chart.Request strings, e.g. "ios/US/finance/free/iphone".
+type Summary struct {
+	Succeeded []string  `json:"succeeded"`
+	Retried   []string  `json:"retried"` // succeeded, but only after a retry
+	Failed    []Failure `json:"failed"`
+	Unsaved   string    `json:"unsaved,omitempty"` // why the run's ranks couldn't be written, if they couldn't
+}
+
+// Failure is a chart that couldn't be scraped or saved.
+type Failure struct {
+	Chart string `json:"chart"`
+	Stage string `json:"stage,omitempty"`
+	Error string `json:"error"`
+}
+
+// Title is a one-line description of the message.
+func (m Message) Title() string {
+	switch m.Event {
+	case watchlist.EventAlerts:
+		if len(m.Alerts) == 1 {
+			return fmt.Sprintf("appcheck %s: 1 alert", m.Command)
+		}
+		return fmt.Sprintf("appcheck %s: %d alerts", m.Command, len(m.Alerts))
+	case watchlist.EventRuns:
+		if m.Summary != nil {
+			s := m.Summary
+			total := len(s.Succeeded) + len(s.Retried) + len(s.Failed)
+			return fmt.Sprintf("appcheck %s: %d of %d charts succeeded", m.Command, total-len(s.Failed), total)
+		}
+	}
+	return "appcheck " + m.Command
+}
+
+// Lines are the message's details, one per alert, retried chart or failure.
+func (m Message) Lines() []string {
+	var lines []string
+	for _, a := range m.Alerts {
+		lines = append(lines, a.Message)
+	}
+	if s := m.Summary; s != nil {
+		for _, c := range s.Retried {
+			lines = append(lines, "Retried "+c)
+		}
+		for _, f := range s.Failed {
+			lines = append(lines, fmt.Sprintf("Failed %s: %s", f.Chart, f.Error))
+		}
+		if s.Unsaved != "" {
+			lines = append(lines, "Ranks not saved: "+s.Unsaved)
+		}
+	}
+	return lines
+}
+
+// Text is the title followed by the lines, as plain text.
+func (m Message) Text() string {
+	return strings.Join(append([]string{m.Title()}, m.Lines()...), "\n")
+}
@@ -0,0 +1,111 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"myproject/internal/stage"
+	"myproject/internal/watchlist"
+)
+
+// Signature headers on signed json webhooks. The signature is
+// "sha256=" followed by the hex HMAC-SHA256, keyed with the secret, of the
+// timestamp, a dot and the request body, so receivers can reject both
+// forged and replayed requests.
+const (
+	SignatureHeader = "X-Appcheck-Signature"
+	TimestampHeader = "X-Appcheck-Timestamp"
+)
+
+// Webhook POSTs messages to a URL.
+type Webhook struct {
+	Config watchlist.Webhook
+	Secret []byte // signs json payloads when set
+	Client *http.Client
+}
+
+// Name implements Sink.
+func (h *Webhook) Name() string {
+	return "webhook " + h.Config.Name
+}
+
+// Wants implements Sink.
+func (h *Webhook) Wants(event string) bool {
+	return h.Config.Wants(event)
+}
+
+// Send implements Sink. Network failures and 5xx or 429 responses are
+// labelled as network errors so they are retried; other responses are not.
+func (h *Webhook) Send(ctx context.Context, m Message) error {
+	body, err := h.payload(m)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, h.Config.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if len(h.Secret) > 0 {
+		ts := strconv.FormatInt(time.Now().Unix(), 10)
+		req.Header.Set(TimestampHeader, ts)
+		req.Header.Set(SignatureHeader, Sign(h.Secret, ts, body))
+	}
+
+	client := h.Client
+	if client == nil {
+		client = &http.Client{Timeout: 30 * time.Second}
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return stage.Wrap(stage.Network, err)
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return stage.StatusError(resp.StatusCode, resp.Status)
+	}
+	return nil
+}
+
+// payload encodes m in the webhook's format.
+func (h *Webhook) payload(m Message) ([]byte, error) {
+	switch h.Config.Format {
+	case watchlist.WebhookSlack:
+		text := "*" + m.Title() + "*"
+		for _, line := range m.Lines() {
+			text += "\n• " + line
+		}
+		return json.Marshal(map[string]string{"text": text})
+	case watchlist.WebhookTeams:
+		// Teams renders single newlines as spaces
+		return json.Marshal(map[string]string{
+			"@type":    "MessageCard",
+			"@context": "https://schema.org/extensions",
+			"summary":  m.Title(),
+			"title":    m.Title(),
+			"text":     strings.Join(m.Lines(), "\n\n"),
+		})
+	}
+	return json.Marshal(m)
+}
+
+// Sign returns the signature header value for body sent at timestamp ts.
+func Sign(secret []byte, ts string, body []byte) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(ts))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
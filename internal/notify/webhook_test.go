@@ -0,0 +1,186 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"myproject/internal/alert"
+	"myproject/internal/chart"
+	"myproject/internal/fetch"
+	"myproject/internal/watchlist"
+)
+
+var testMessage = Message{
+	Event:   watchlist.EventAlerts,
+	Command: "multi",
+	At:      time.Date(2024, 10, 28, 9, 0, 0, 0, time.UTC),
+	Alerts: []alert.Alert{{
+		Rule:    "big-move",
+		Kind:    watchlist.AlertMoved,
+		App:     "Coinbase",
+		Chart:   chart.Request{Store: chart.StoreIOS, Device: chart.DeviceIPhone, Country: "US", Category: "finance", List: "free"},
+		From:    30,
+		To:      45,
+		Message: "Coinbase moved down 15 places from #30 to #45 in ios/US/finance/free/iphone",
+	}},
+}
+
+func TestWebhookJSONSigned(t *testing.T) {
+	secret := []byte("s3cret")
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if got, want := r.Header.Get(SignatureHeader), Sign(secret, r.Header.Get(TimestampHeader), body); got != want {
+			t.Errorf("signature = %q, want %q", got, want)
+		}
+		var m Message
+		if err := json.Unmarshal(body, &m); err != nil {
+			t.Errorf("decode body: %v", err)
+		}
+		if len(m.Alerts) != 1 || m.Alerts[0].To != 45 || m.Alerts[0].Chart.Country != "US" {
+			t.Errorf("received %+v", m)
+		}
+	}))
+	defer srv.Close()
+
+	h := &Webhook{Config: watchlist.Webhook{Name: "ops", URL: srv.URL, Format: watchlist.WebhookJSON}, Secret: secret}
+	if err := h.Send(context.Background(), testMessage); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestWebhookSlack(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var payload struct{ Text string }
+		if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+			t.Errorf("decode body: %v", err)
+		}
+		want := "*appcheck multi: 1 alert*\n• " + testMessage.Alerts[0].Message
+		if payload.Text != want {
+			t.Errorf("text = %q, want %q", payload.Text, want)
+		}
+		if r.Header.Get(SignatureHeader) != "" {
+			t.Error("slack payload was signed")
+		}
+	}))
+	defer srv.Close()
+
+	h := &Webhook{Config: watchlist.Webhook{Name: "slack", URL: srv.URL, Format: watchlist.WebhookSlack}}
+	if err := h.Send(context.Background(), testMessage); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestWebhookTeams(t *testing.T) {
+	m := testMessage
+	m.Event = watchlist.EventRuns
+	m.Summary = &Summary{
+		Retried: []string{"ios/GB/finance/free/iphone"},
+		Failed:  []Failure{{Chart: "play/US/finance/free", Stage: "rejected", Error: "status code error: 404 Not Found"}},
+		Unsaved: "storage: write records.csv: no space left on device",
+	}
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if ct := r.Header.Get("Content-Type"); ct != "application/json" {
+			t.Errorf("content type = %q", ct)
+		}
+		var card map[string]string
+		if err := json.NewDecoder(r.Body).Decode(&card); err != nil {
+			t.Errorf("decode body: %v", err)
+		}
+		want := map[string]string{
+			"@type":    "MessageCard",
+			"@context": "https://schema.org/extensions",
+			"summary":  m.Title(),
+			"title":    m.Title(),
+			"text": testMessage.Alerts[0].Message +
+				"\n\nRetried ios/GB/finance/free/iphone" +
+				"\n\nFailed play/US/finance/free: status code error: 404 Not Found" +
+				"\n\nRanks not saved: storage: write records.csv: no space left on device",
+		}
+		for k, v := range want {
+			if card[k] != v {
+				t.Errorf("%s = %q, want %q", k, card[k], v)
+			}
+		}
+		if len(card) != len(want) {
+			t.Errorf("card has fields %v", card)
+		}
+	}))
+	defer srv.Close()
+
+	h := &Webhook{Config: watchlist.Webhook{Name: "teams", URL: srv.URL, Format: watchlist.WebhookTeams}}
+	if err := h.Send(context.Background(), m); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// TestDispatcherRetry checks that 5xx responses are retried until they
+// succeed, that 4xx responses aren't, and that a message that never gets
+// through ends up in the dead-letter file.
+func TestDispatcherRetry(t *testing.T) {
+	var flakyHits, brokenHits, rejectHits atomic.Int32
+	mux := http.NewServeMux()
+	mux.HandleFunc("/flaky", func(w http.ResponseWriter, r *http.Request) {
+		if flakyHits.Add(1) < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	})
+	mux.HandleFunc("/broken", func(w http.ResponseWriter, r *http.Request) {
+		brokenHits.Add(1)
+		w.WriteHeader(http.StatusInternalServerError)
+	})
+	mux.HandleFunc("/reject", func(w http.ResponseWriter, r *http.Request) {
+		rejectHits.Add(1)
+		w.WriteHeader(http.StatusBadRequest)
+	})
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	sink := func(name string, events ...string) Sink {
+		return &Webhook{Config: watchlist.Webhook{Name: name, URL: srv.URL + "/" + name, Format: watchlist.WebhookJSON, Events: events}}
+	}
+	d := &Dispatcher{
+		Sinks:      []Sink{sink("flaky"), sink("broken"), sink("reject"), sink("runs-only", watchlist.EventRuns)},
+		Backoff:    fetch.Backoff{Retries: 3, Initial: time.Millisecond},
+		DeadLetter: filepath.Join(t.TempDir(), "undelivered.jsonl"),
+	}
+
+	err := d.Send(context.Background(), testMessage)
+	if err == nil || !strings.Contains(err.Error(), "webhook broken") || !strings.Contains(err.Error(), "webhook reject") {
+		t.Errorf("Send error = %v, want failures from broken and reject", err)
+	}
+	if n := flakyHits.Load(); n != 3 {
+		t.Errorf("flaky webhook hit %d times, want 3", n)
+	}
+	if n := brokenHits.Load(); n != 4 {
+		t.Errorf("broken webhook hit %d times, want 4", n)
+	}
+	if n := rejectHits.Load(); n != 1 {
+		t.Errorf("rejecting webhook hit %d times, want 1", n)
+	}
+
+	data, err := os.ReadFile(d.DeadLetter)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("dead-letter file has %d lines, want 2:\n%s", len(lines), data)
+	}
+	var dl deadLetter
+	if err := json.Unmarshal([]byte(lines[0]), &dl); err != nil {
+		t.Fatal(err)
+	}
+	if dl.Sink != "webhook broken" || dl.Attempts != 4 || len(dl.Message.Alerts) != 1 {
+		t.Errorf("dead letter = %+v", dl)
+	}
+}
@@ -0,0 +1,107 @@
+// Package replay records rendered chart pages to disk and serves them back
+// from a local HTTP server, so the parsing and storage path can run without
+// the live site or a browser.
+package replay
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"myproject/internal/chart"
+	"myproject/internal/fetch"
+)
+
+// Fixture describes one recorded page. It is stored as JSON next to the HTML.
+type Fixture struct {
+	URL        string        `json:"url"`
+	Request    chart.Request `json:"request"`
+	RecordedAt time.Time     `json:"recorded_at"`
+	HTMLFile   string        `json:"html_file"` // relative to the fixture directory
+}
+
+// Recorder is a fetch.Loader that saves every page it loads under Dir. The
+// chart request is taken from the context when the source put one there.
+type Recorder struct {
+	Loader fetch.Loader
+	Dir    string
+}
+
+// Load implements fetch.Loader. A page that can't be saved is still returned.
+func (r *Recorder) Load(ctx context.Context, url string) (string, error) {
+	html, err := r.Loader.Load(ctx, url)
+	if err != nil {
+		return "", err
+	}
+
+	req, _ := chart.RequestFrom(ctx)
+	f := Fixture{URL: url, Request: req, RecordedAt: time.Now()}
+	if err := Save(r.Dir, f, html); err != nil {
+		log.Printf("Error recording %s: %v", url, err)
+	}
+	return html, nil
+}
+
+// Save writes html and its fixture description to dir.
+func Save(dir string, f Fixture, html string) error {
+	if err := os.MkdirAll(dir, os.ModePerm); err != nil {
+		return fmt.Errorf("create fixture directory: %w", err)
+	}
+
+	name := fixtureName(f)
+	f.HTMLFile = name + ".html"
+	if err := os.WriteFile(filepath.Join(dir, f.HTMLFile), []byte(html), 0o644); err != nil {
+		return err
+	}
+
+	meta, err := json.MarshalIndent(f, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(dir, name+".json"), append(meta, '\n'), 0o644)
+}
+
+// fixtureName is e.g.
+// "ios-iphone_united-states_finance_free_2024-10-21_15-03-55".
+func fixtureName(f Fixture) string {
+	store := f.Request.Store
+	if f.Request.Device != "" {
+		store += "-" + f.Request.Device
+	}
+	parts := []string{store, f.Request.Country, f.Request.Category, f.Request.List}
+	if f.Request == (chart.Request{}) {
+		parts = []string{"page"}
+	}
+	parts = append(parts, f.RecordedAt.Format("2006-01-02_15-04-05"))
+	return strings.Join(parts, "_")
+}
+
+// Load reads every fixture description in dir.
+func Load(dir string) ([]Fixture, error) {
+	paths, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return nil, err
+	}
+
+	var fixtures []Fixture
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		var f Fixture
+		if err := json.Unmarshal(data, &f); err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		if f.URL == "" || f.HTMLFile == "" {
+			continue
+		}
+		fixtures = append(fixtures, f)
+	}
+	return fixtures, nil
+}
@@ -0,0 +1,69 @@
+package replay
+
+import (
+	"context"
+	"testing"
+
+	"myproject/internal/appfigures"
+	"myproject/internal/chart"
+)
+
+const page = `<html><body>
+<div class="s-1362551351-0"><a class="s-4262409-0" href="https://apps.apple.com/us/app/cash-app/id711923939">1. Cash App</a></div>
+<div class="s-1362551351-0"><a class="s-4262409-0" href="https://apps.apple.com/us/app/coinbase/id886427730">2. Coinbase: Buy Bitcoin &amp; Ether</a></div>
+</body></html>`
+
+// pageLoader stands in for the browser and always returns page.
+type pageLoader struct{}
+
+func (pageLoader) Load(ctx context.Context, url string) (string, error) {
+	return page, nil
+}
+
+// TestRecordAndReplay records a chart through the appfigures source, then
+// fetches it again from the replay server and checks both parses agree.
+func TestRecordAndReplay(t *testing.T) {
+	dir := t.TempDir()
+	req := chart.Request{Store: chart.StoreIOS, Country: "US", Category: "finance", List: "free"}
+
+	recorded, err := appfigures.NewIOSSource(&Recorder{Loader: pageLoader{}, Dir: dir}).Fetch(context.Background(), req)
+	if err != nil {
+		t.Fatalf("record: %v", err)
+	}
+
+	fixtures, err := Load(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(fixtures) != 1 {
+		t.Fatalf("recorded %d fixtures, want 1", len(fixtures))
+	}
+	if f := fixtures[0]; f.Request != req || f.URL != appfigures.ChartURL(req) {
+		t.Errorf("fixture = %+v, want request %+v at %s", f, req, appfigures.ChartURL(req))
+	}
+
+	srv, err := NewServer(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer srv.Close()
+
+	replayed, err := appfigures.NewIOSSource(srv.Loader()).Fetch(context.Background(), req)
+	if err != nil {
+		t.Fatalf("replay: %v", err)
+	}
+	if len(replayed.Entries) != 2 || replayed.Depth != 2 {
+		t.Fatalf("replayed %d entries to depth %d, want 2 and 2", len(replayed.Entries), replayed.Depth)
+	}
+	for i, e := range replayed.Entries {
+		if e != recorded.Entries[i] {
+			t.Errorf("entry %d = %+v, want %+v", i, e, recorded.Entries[i])
+		}
+	}
+
+	other := req
+	other.Country = "GB"
+	if _, err := appfigures.NewIOSSource(srv.Loader()).Fetch(context.Background(), other); err == nil {
+		t.Errorf("replaying an unrecorded chart succeeded, want an error")
+	}
+}
@@ -0,0 +1,110 @@
+package replay
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"net/url"
+	"path/filepath"
+
+	"myproject/internal/fetch"
+)
+
+// Server serves recorded pages on a local port in place of the live site.
+type Server struct {
+	URL string // base URL, e.g. "http://127.0.0.1:54321"
+
+	srv   *http.Server
+	pages map[string]string // request URI -> HTML file
+}
+
+// NewServer starts serving the fixtures in dir. When a page was recorded more
+// than once, the latest recording is served.
+func NewServer(dir string) (*Server, error) {
+	fixtures, err := Load(dir)
+	if err != nil {
+		return nil, err
+	}
+	if len(fixtures) == 0 {
+		return nil, fmt.Errorf("no fixtures in %s", dir)
+	}
+
+	s := &Server{pages: make(map[string]string)}
+	latest := make(map[string]Fixture)
+	for _, f := range fixtures {
+		key, err := requestURI(f.URL)
+		if err != nil {
+			return nil, fmt.Errorf("fixture %s: %w", f.HTMLFile, err)
+		}
+		if prev, ok := latest[key]; ok && prev.RecordedAt.After(f.RecordedAt) {
+			continue
+		}
+		latest[key] = f
+		s.pages[key] = filepath.Join(dir, f.HTMLFile)
+	}
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return nil, err
+	}
+	s.URL = "http://" + ln.Addr().String()
+	s.srv = &http.Server{Handler: s}
+	go func() {
+		if err := s.srv.Serve(ln); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Printf("Replay server stopped: %v", err)
+		}
+	}()
+	return s, nil
+}
+
+// ServeHTTP serves the page recorded for the request's path and query.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	path, ok := s.pages[r.URL.RequestURI()]
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	http.ServeFile(w, r, path)
+}
+
+// Close stops the server.
+func (s *Server) Close() error {
+	return s.srv.Shutdown(context.Background())
+}
+
+// Loader returns a fetch.Loader that fetches live URLs from the server
+// instead.
+func (s *Server) Loader() fetch.Loader {
+	return loader{base: s.URL}
+}
+
+type loader struct {
+	base string
+}
+
+// Load implements fetch.Loader.
+func (l loader) Load(ctx context.Context, rawURL string) (string, error) {
+	uri, err := requestURI(rawURL)
+	if err != nil {
+		return "", err
+	}
+	html, err := fetch.HTTPLoader{Client: http.DefaultClient}.Load(ctx, l.base+uri)
+	if err != nil {
+		return "", fmt.Errorf("replay %s: %w", rawURL, err)
+	}
+	return html, nil
+}
+
+// requestURI is the path and query of rawURL, which is what fixtures are
+// served under.
+func requestURI(rawURL string) (string, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return "", err
+	}
+	return u.RequestURI(), nil
+}
@@ -0,0 +1,122 @@
+// Package schedule runs recurring jobs on cron expressions or fixed
+// intervals, one at a time, until it is told to stop.
+package schedule
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"runtime/debug"
+	"sync"
+	"time"
+
+	"github.com/robfig/cron/v3"
+
+	"myproject/internal/fetch"
+)
+
+// Schedule tells when a job next runs after a given time.
+type Schedule interface {
+	Next(time.Time) time.Time
+}
+
+// Parse returns the schedule for a standard five-field cron expression or,
+// when expr is empty, for a fixed interval.
+func Parse(expr string, every time.Duration) (Schedule, error) {
+	switch {
+	case expr != "" && every != 0:
+		return nil, errors.New("set either a cron expression or an interval, not both")
+	case expr != "":
+		s, err := cron.ParseStandard(expr)
+		if err != nil {
+			return nil, fmt.Errorf("cron %q: %w", expr, err)
+		}
+		return s, nil
+	case every > 0:
+		return interval(every), nil
+	}
+	return nil, errors.New("no cron expression or interval")
+}
+
+// interval runs a job every so often, counted from when the last run ended.
+type interval time.Duration
+
+func (i interval) Next(t time.Time) time.Time {
+	return t.Add(time.Duration(i))
+}
+
+// Job is one recurring task.
+type Job struct {
+	Name     string
+	Schedule Schedule
+	Jitter   time.Duration // random delay added to every run
+	Run      func() error
+}
+
+// Scheduler runs jobs. Only one job runs at a time: a job that comes due
+// while another is running waits for it, and a job never overlaps itself
+// because its next run is only worked out once the current one is over.
+type Scheduler struct {
+	Jobs []Job
+
+	mu sync.Mutex // held while a job runs
+}
+
+// Run schedules every job and blocks until ctx is cancelled and any job that
+// was running has finished. Running jobs aren't interrupted, so their writes
+// complete.
+func (s *Scheduler) Run(ctx context.Context) {
+	var wg sync.WaitGroup
+	for _, job := range s.Jobs {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			s.loop(ctx, job)
+		}()
+	}
+	wg.Wait()
+}
+
+func (s *Scheduler) loop(ctx context.Context, job Job) {
+	for {
+		next := job.Schedule.Next(time.Now()).Add(fetch.Jitter(job.Jitter))
+		log.Printf("Job %s: next run at %s", job.Name, next.Format(time.DateTime))
+
+		timer := time.NewTimer(time.Until(next))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+
+		s.mu.Lock()
+		if ctx.Err() != nil {
+			// Shutdown began while waiting for another job
+			s.mu.Unlock()
+			return
+		}
+		s.run(job)
+		s.mu.Unlock()
+	}
+}
+
+// run runs job once. A failing or panicking job is logged and left to try
+// again on its next run.
+func (s *Scheduler) run(job Job) {
+	start := time.Now()
+	log.Printf("Job %s: starting", job.Name)
+
+	defer func() {
+		if r := recover(); r != nil {
+			log.Printf("Job %s: panic: %v\n%s", job.Name, r, debug.Stack())
+		}
+	}()
+
+	if err := job.Run(); err != nil {
+		log.Printf("Job %s: failed after %s: %v", job.Name, time.Since(start).Round(time.Second), err)
+		return
+	}
+	log.Printf("Job %s: finished in %s", job.Name, time.Since(start).Round(time.Second))
+}
@@ -0,0 +1,123 @@
+package schedule
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+const tick = 5 * time.Millisecond
+
+func every(t *testing.T, d time.Duration) Schedule {
+	t.Helper()
+	s, err := Parse("", d)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+// runFor runs s until stop reports true or the deadline passes, and fails the
+// test if Run doesn't return once cancelled.
+func runFor(t *testing.T, s *Scheduler, stop func() bool) {
+	t.Helper()
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		s.Run(ctx)
+		close(done)
+	}()
+
+	deadline := time.After(5 * time.Second)
+	for !stop() {
+		select {
+		case <-deadline:
+			cancel()
+			<-done
+			t.Fatal("timed out")
+		case <-time.After(tick):
+		}
+	}
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Run didn't return after cancel")
+	}
+}
+
+func TestJobsDontOverlap(t *testing.T) {
+	var running, overlaps, runs atomic.Int32
+	run := func() error {
+		if running.Add(1) > 1 {
+			overlaps.Add(1)
+		}
+		time.Sleep(3 * tick)
+		running.Add(-1)
+		runs.Add(1)
+		return nil
+	}
+	s := &Scheduler{Jobs: []Job{
+		{Name: "a", Schedule: every(t, tick), Run: run},
+		{Name: "b", Schedule: every(t, tick), Run: run},
+	}}
+
+	runFor(t, s, func() bool { return runs.Load() >= 6 })
+	if n := overlaps.Load(); n > 0 {
+		t.Errorf("jobs overlapped %d times", n)
+	}
+}
+
+func TestJobRunsAgainAfterFailure(t *testing.T) {
+	var runs atomic.Int32
+	s := &Scheduler{Jobs: []Job{{
+		Name:     "flaky",
+		Schedule: every(t, tick),
+		Run: func() error {
+			switch runs.Add(1) {
+			case 1:
+				panic("boom")
+			case 2:
+				return errors.New("failed")
+			}
+			return nil
+		},
+	}}}
+
+	// A third run means neither the panic nor the error stopped the job
+	runFor(t, s, func() bool { return runs.Load() >= 3 })
+}
+
+func TestRunWaitsForRunningJob(t *testing.T) {
+	started := make(chan struct{})
+	var finished atomic.Bool
+	s := &Scheduler{Jobs: []Job{{
+		Name:     "slow",
+		Schedule: every(t, tick),
+		Run: func() error {
+			if finished.Load() {
+				return nil
+			}
+			close(started)
+			time.Sleep(20 * tick)
+			finished.Store(true)
+			return nil
+		},
+	}}}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		s.Run(ctx)
+		close(done)
+	}()
+
+	<-started
+	cancel()
+	<-done
+	if !finished.Load() {
+		t.Error("Run returned before the running job finished")
+	}
+}
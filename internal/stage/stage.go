@@ -0,0 +1,99 @@
+// Package stage labels errors with the step of a scrape that produced them,
+// so a run can report failures by kind and retry only the transient ones.
+package stage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+)
+
+// Stage is the kind of step that failed.
+type Stage string
+
+const (
+	Network  Stage = "network"            // the page or feed couldn't be fetched
+	Rejected Stage = "rejected"           // the server refused the request, e.g. with a 404
+	Timeout  Stage = "timeout"            // a fetch or render ran out of time
+	Selector Stage = "selector-not-found" // the page loaded without the chart rows
+	Parse    Stage = "parse"              // the page loaded but couldn't be read
+	Storage  Stage = "storage"            // the results couldn't be written
+)
+
+// Error is an error from one stage.
+type Error struct {
+	Stage Stage
+	Err   error
+}
+
+func (e *Error) Error() string {
+	return string(e.Stage) + ": " + e.Err.Error()
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// Wrap labels err with s. An error that already has a stage keeps it, since
+// the innermost label is the most precise, and a network error caused by a
+// deadline is labelled a timeout. Wrap returns nil for a nil err.
+func Wrap(s Stage, err error) error {
+	if err == nil {
+		return nil
+	}
+	var e *Error
+	if errors.As(err, &e) {
+		return err
+	}
+	if s == Network && isTimeout(err) {
+		s = Timeout
+	}
+	return &Error{Stage: s, Err: err}
+}
+
+// Of returns the stage of err, or "" when it has none and isn't recognisably
+// a network failure or timeout.
+func Of(err error) Stage {
+	var e *Error
+	switch {
+	case err == nil:
+		return ""
+	case errors.As(err, &e):
+		return e.Stage
+	case isTimeout(err):
+		return Timeout
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		return Network
+	}
+	return ""
+}
+
+// StatusError is the error for an unwanted HTTP response status. Server
+// errors and 429 Too Many Requests are Network, and so retried; any other
+// status, such as a 404, is Rejected and won't get better by asking again.
+func StatusError(code int, status string) error {
+	err := fmt.Errorf("status code error: %d %s", code, status)
+	if code >= 500 || code == http.StatusTooManyRequests {
+		return &Error{Stage: Network, Err: err}
+	}
+	return &Error{Stage: Rejected, Err: err}
+}
+
+// Transient reports whether err came from a stage worth retrying: a network
+// failure or a timeout.
+func Transient(err error) bool {
+	s := Of(err)
+	return s == Network || s == Timeout
+}
+
+func isTimeout(err error) bool {
+	if errors.Is(err, context.DeadlineExceeded) {
+		return true
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr) && netErr.Timeout()
+}
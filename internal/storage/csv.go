@@ -0,0 +1,88 @@
+// Package storage writes scrape results to CSV files under the results folder.
+package storage
+
+import (
+	"encoding/csv"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"myproject/internal/chart"
+)
+
+// Dir is the folder all result files are written to.
+const Dir = "results"
+
+// ChartPath returns results/apps_<country>_<store>[-<device>]_<category>_<list>_<timestamp>.csv,
+// with the country code in lower case.
+func ChartPath(req chart.Request, t time.Time) string {
+	store := req.Store
+	if req.Device != "" {
+		store += "-" + req.Device
+	}
+	name := fmt.Sprintf("apps_%s_%s_%s_%s_%s.csv", strings.ToLower(req.Country), store, req.Category, req.List, t.Format("2006-01-02_15-04-05"))
+	return filepath.Join(Dir, name)
+}
+
+// WriteChart writes a full chart as Rank,Name,Pricing,Developer,IAP,Icon rows.
+func WriteChart(path string, entries []chart.Entry) error {
+	rows := [][]string{{"Rank", "Name", "Pricing", "Developer", "IAP", "Icon"}}
+	for _, e := range entries {
+		rows = append(rows, []string{strconv.Itoa(e.Rank), e.Name, e.Pricing, e.Developer, strconv.FormatBool(e.IAP), e.Icon})
+	}
+	return write(path, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, rows)
+}
+
+// AppendRow appends row to path, writing the header rows first if the file is
+// new or empty.
+func AppendRow(path string, header [][]string, row []string) error {
+	if err := ensureDir(path); err != nil {
+		return err
+	}
+
+	info, err := os.Stat(path)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	var rows [][]string
+	if info == nil || info.Size() == 0 {
+		rows = append(rows, header...)
+	}
+	rows = append(rows, row)
+
+	return write(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, rows)
+}
+
+func write(path string, flag int, rows [][]string) error {
+	if err := ensureDir(path); err != nil {
+		return err
+	}
+
+	file, err := os.OpenFile(path, flag, 0644)
+	if err != nil {
+		return fmt.Errorf("open %s: %w", path, err)
+	}
+
+	writer := csv.NewWriter(file)
+	if err := writer.WriteAll(rows); err != nil {
+		file.Close()
+		return fmt.Errorf("write %s: %w", path, err)
+	}
+
+	if err := file.Close(); err != nil {
+		return fmt.Errorf("close %s: %w", path, err)
+	}
+	return nil
+}
+
+// Ensure the results directory exists
+func ensureDir(path string) error {
+	if err := os.MkdirAll(filepath.Dir(path), os.ModePerm); err != nil {
+		return fmt.Errorf("create results directory: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,162 @@
+package storage
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"myproject/internal/catalog"
+	"myproject/internal/chart"
+)
+
+// RecordsPath is the canonical rank history, one row per app per chart per run.
+var RecordsPath = filepath.Join(Dir, "ranks.csv")
+
+// Rank statuses.
+const (
+	StatusRanked      = "ranked"             // the app was found in the chart
+	StatusBelowDepth  = "below-loaded-depth" // the chart loaded but the app wasn't in the part that loaded
+	StatusChartFailed = "chart-failed"       // the chart couldn't be fetched or parsed
+	StatusNotMatched  = "app-not-matched"    // entries resembled the app but none could be matched with certainty
+)
+
+// legacyStatusNotFound is what StatusBelowDepth was called before depth was
+// recorded.
+const legacyStatusNotFound = "not-found"
+
+// recordHeader grows at the end only, so files started by an older version
+// still line up.
+var recordHeader = []string{"timestamp", "store", "country", "category", "list", "app_id", "app_name", "rank", "status", "depth", "device"}
+
+// minRecordFields is the width of files written before depth and device were
+// recorded.
+const minRecordFields = 9
+
+// Record is one tracked app's position in one chart at one point in time.
+type Record struct {
+	Time     time.Time
+	Store    string
+	Country  string
+	Category string
+	List     string
+	AppID    string
+	AppName  string
+	Rank     int // 0 unless Status is StatusRanked
+	Status   string
+	Depth    int    // last rank the chart loaded to; 0 when unknown
+	Device   string // chart.DeviceIPhone or chart.DeviceIPad for App Store charts
+}
+
+func (r Record) row() []string {
+	return []string{
+		r.Time.Format(time.RFC3339), r.Store, r.Country, r.Category, r.List,
+		r.AppID, r.AppName, optionalInt(r.Rank), r.Status, optionalInt(r.Depth), r.Device,
+	}
+}
+
+func optionalInt(v int) string {
+	if v == 0 {
+		return ""
+	}
+	return strconv.Itoa(v)
+}
+
+// AppendRecords appends records to path, writing the header if the file is new.
+func AppendRecords(path string, records []Record) error {
+	rows := make([][]string, 0, len(records))
+	for _, r := range records {
+		rows = append(rows, r.row())
+	}
+	if err := ensureDir(path); err != nil {
+		return err
+	}
+
+	info, err := os.Stat(path)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	if info == nil || info.Size() == 0 {
+		rows = append([][]string{recordHeader}, rows...)
+	}
+
+	return write(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, rows)
+}
+
+// ReadRecords loads every record in path.
+func ReadRecords(path string) ([]Record, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	// Files written by older versions have fewer columns
+	reader := csv.NewReader(file)
+	reader.FieldsPerRecord = -1
+
+	var records []Record
+	for line := 1; ; line++ {
+		row, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if len(row) < minRecordFields || len(row) > len(recordHeader) {
+			return nil, fmt.Errorf("%s line %d: expected %d fields, got %d", path, line, len(recordHeader), len(row))
+		}
+		if line == 1 && row[0] == recordHeader[0] {
+			continue
+		}
+
+		r, err := parseRecord(row)
+		if err != nil {
+			return nil, fmt.Errorf("%s line %d: %w", path, line, err)
+		}
+		records = append(records, r)
+	}
+	return records, nil
+}
+
+func parseRecord(row []string) (Record, error) {
+	t, err := time.Parse(time.RFC3339, row[0])
+	if err != nil {
+		return Record{}, err
+	}
+
+	// Older files spell countries as appfigures slugs
+	r := Record{
+		Time: t, Store: row[1], Country: catalog.CountryCode(row[2]), Category: row[3], List: row[4],
+		AppID: row[5], AppName: row[6], Status: row[8],
+	}
+	if r.Status == legacyStatusNotFound {
+		r.Status = StatusBelowDepth
+	}
+	if r.Rank, err = parseOptionalInt(row[7]); err != nil {
+		return Record{}, fmt.Errorf("bad rank %q", row[7])
+	}
+	if len(row) > 9 {
+		if r.Depth, err = parseOptionalInt(row[9]); err != nil {
+			return Record{}, fmt.Errorf("bad depth %q", row[9])
+		}
+	}
+	if len(row) > 10 {
+		r.Device = row[10]
+	} else {
+		// Before devices were recorded every App Store chart was the iPhone one
+		r.Device = chart.DefaultDevice(r.Store)
+	}
+	return r, nil
+}
+
+func parseOptionalInt(s string) (int, error) {
+	if s == "" {
+		return 0, nil
+	}
+	return strconv.Atoi(s)
+}
@@ -0,0 +1,82 @@
+package storage
+
+import (
+	"os"
+	"strconv"
+)
+
+// ChartKey groups the records of one chart.
+type ChartKey struct {
+	Store, Device, Country, Category, List string
+}
+
+// Key returns the chart the record belongs to.
+func (r Record) Key() ChartKey {
+	return ChartKey{r.Store, r.Device, r.Country, r.Category, r.List}
+}
+
+// WriteWide exports records in the spreadsheet layout the team has been
+// reading: a blank line, a Date/Time row, a heading per chart spanning its
+// apps, a row of app names, then one row per run. Charts and apps appear in
+// the order they are first seen; label supplies the chart headings.
+func WriteWide(path string, records []Record, label func(ChartKey) string) error {
+	type column struct {
+		chart ChartKey
+		app   string
+	}
+
+	var charts []ChartKey
+	appsByChart := make(map[ChartKey][]string)
+	seen := make(map[column]bool)
+
+	var times []string
+	cells := make(map[string]map[column]string)
+
+	for _, r := range records {
+		col := column{r.Key(), r.AppName}
+		if !seen[col] {
+			if _, ok := appsByChart[col.chart]; !ok {
+				charts = append(charts, col.chart)
+			}
+			appsByChart[col.chart] = append(appsByChart[col.chart], col.app)
+			seen[col] = true
+		}
+
+		stamp := r.Time.Format("2006-01-02 15:04:05")
+		if _, ok := cells[stamp]; !ok {
+			times = append(times, stamp)
+			cells[stamp] = make(map[column]string)
+		}
+		if r.Status == StatusRanked {
+			cells[stamp][col] = strconv.Itoa(r.Rank)
+		}
+	}
+
+	timeRow := []string{"Date", "Time"}
+	chartRow := []string{"", ""}
+	appRow := []string{"", ""}
+	var cols []column
+	for _, c := range charts {
+		for i, app := range appsByChart[c] {
+			heading := ""
+			if i == 0 {
+				heading = label(c)
+			}
+			timeRow = append(timeRow, "")
+			chartRow = append(chartRow, heading)
+			appRow = append(appRow, app)
+			cols = append(cols, column{c, app})
+		}
+	}
+
+	rows := [][]string{{}, timeRow, chartRow, appRow}
+	for _, stamp := range times {
+		row := []string{stamp[:10], stamp[11:]}
+		for _, col := range cols {
+			row = append(row, cells[stamp][col])
+		}
+		rows = append(rows, row)
+	}
+
+	return write(path, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, rows)
+}
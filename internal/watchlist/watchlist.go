@@ -0,0 +1,380 @@
+// Package watchlist loads the YAML file that declares which apps and charts
+// we track.
+package watchlist
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"myproject/internal/catalog"
+	"myproject/internal/chart"
+)
+
+// DefaultPath is where commands look for the watchlist unless told otherwise.
+const DefaultPath = "watchlist.yaml"
+
+// App is one tracked app.
+type App struct {
+	Name        string   `yaml:"name"`         // display name, e.g. "Trust Wallet"
+	Key         string   `yaml:"key"`          // short ID used in column names; defaults to Name without spaces
+	IOSID       string   `yaml:"ios_id"`       // App Store track ID
+	PlayPackage string   `yaml:"play_package"` // Google Play package name
+	Aliases     []string `yaml:"aliases"`      // chart names to fall back on when a chart has no IDs
+}
+
+// Watchlist is the parsed config file.
+type Watchlist struct {
+	Apps       []App       `yaml:"apps"`
+	Countries  []string    `yaml:"countries"`
+	Stores     []string    `yaml:"stores"`
+	Categories []string    `yaml:"categories"`
+	Lists      []string    `yaml:"lists"`
+	Devices    []string    `yaml:"devices"` // App Store charts only
+	Jobs       []Job       `yaml:"jobs"`    // runs for the serve command
+	Alerts     []AlertRule `yaml:"alerts"`  // checked after every tracking run
+	Notify     Notify      `yaml:"notify"`  // where alerts, run summaries and the digest are sent
+}
+
+// Job commands.
+const (
+	JobTrack  = "track"  // record the tracked apps' ranks
+	JobDigest = "digest" // mail the previous day's digest
+)
+
+// Job is a recurring run for the serve command. It sets either Cron or Every.
+type Job struct {
+	Name    string        `yaml:"name"`
+	Command string        `yaml:"command"` // one of the Job commands; track by default
+	Cron    string        `yaml:"cron"`    // five-field cron expression, e.g. "0 * * * *"
+	Every   time.Duration `yaml:"every"`   // fixed interval, e.g. "30m"
+	Jitter  time.Duration `yaml:"jitter"`  // random delay added to each run
+	Stores  []string      `yaml:"stores"`  // limit the run to these stores; all by default
+}
+
+// Alert rule kinds.
+const (
+	AlertMoved    = "moved"    // the rank changed by at least Places
+	AlertTop      = "top"      // the app entered or left the top Top
+	AlertOvertook = "overtook" // Competitor moved above the app
+	AlertFellOff  = "fell-off" // the app was ranked and is now below the loaded chart
+)
+
+var alertKinds = []string{AlertMoved, AlertTop, AlertOvertook, AlertFellOff}
+
+// DefaultAlertCooldown is how long a rule stays quiet about an app and chart
+// after alerting on them, unless the rule sets its own cooldown.
+const DefaultAlertCooldown = 6 * time.Hour
+
+// AlertRule raises an alert when a tracked rank changes in a way worth
+// noticing between one run and the next.
+type AlertRule struct {
+	Name       string        `yaml:"name"`
+	Kind       string        `yaml:"kind"`       // one of the Alert kinds
+	Apps       []string      `yaml:"apps"`       // keys of the apps to watch; all apps by default
+	Places     int           `yaml:"places"`     // moved: smallest change worth an alert
+	Top        int           `yaml:"top"`        // top: size of the top, e.g. 10
+	Competitor string        `yaml:"competitor"` // overtook: key of the app that mustn't pass ours
+	Cooldown   time.Duration `yaml:"cooldown"`   // quiet time after an alert for the same app and chart
+}
+
+// Watches reports whether the rule covers app.
+func (r AlertRule) Watches(app App) bool {
+	return len(r.Apps) == 0 || slices.Contains(r.Apps, app.Key)
+}
+
+// Notification events.
+const (
+	EventAlerts = "alerts" // a tracking run raised alerts
+	EventRuns   = "runs"   // a tracking run finished
+)
+
+// Webhook payload formats.
+const (
+	WebhookJSON  = "json"  // the message as JSON, signed when a secret is set
+	WebhookSlack = "slack" // a Slack incoming-webhook message
+	WebhookTeams = "teams" // a Microsoft Teams incoming-webhook message
+)
+
+var (
+	events         = []string{EventAlerts, EventRuns}
+	webhookFormats = []string{WebhookJSON, WebhookSlack, WebhookTeams}
+)
+
+// Notify lists the places notifications go.
+type Notify struct {
+	Webhooks []Webhook `yaml:"webhooks"`
+	Email    *Email    `yaml:"email"` // where the daily digest is mailed
+}
+
+// Webhook is an HTTP endpoint notifications are POSTed to.
+type Webhook struct {
+	Name      string   `yaml:"name"`
+	URL       string   `yaml:"url"`
+	Format    string   `yaml:"format"`     // one of the Webhook formats; json by default
+	SecretEnv string   `yaml:"secret_env"` // environment variable holding the json signing key
+	Events    []string `yaml:"events"`     // which events to send; all by default
+}
+
+// Email TLS modes.
+const (
+	EmailStartTLS = "starttls" // upgrade the connection before authenticating; fail if the server can't
+	EmailNoTLS    = "none"     // plain SMTP, for local relays
+)
+
+// Email is the SMTP server the digest is sent through and who gets it.
+type Email struct {
+	Host        string   `yaml:"host"`
+	Port        int      `yaml:"port"`         // 587 by default
+	TLS         string   `yaml:"tls"`          // one of the Email TLS modes; starttls by default
+	Username    string   `yaml:"username"`     // no authentication when empty
+	PasswordEnv string   `yaml:"password_env"` // environment variable holding the password
+	From        string   `yaml:"from"`
+	To          []string `yaml:"to"`
+}
+
+// Wants reports whether the webhook takes event.
+func (h Webhook) Wants(event string) bool {
+	return len(h.Events) == 0 || slices.Contains(h.Events, event)
+}
+
+// Load reads and validates the watchlist at path.
+func Load(path string) (*Watchlist, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var w Watchlist
+	if err := yaml.Unmarshal(data, &w); err != nil {
+		return nil, fmt.Errorf("parse %s: %w", path, err)
+	}
+	w.setDefaults()
+	w.normalize()
+
+	if err := w.Validate(); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return &w, nil
+}
+
+func (w *Watchlist) setDefaults() {
+	for i := range w.Apps {
+		if w.Apps[i].Key == "" {
+			w.Apps[i].Key = strings.ReplaceAll(w.Apps[i].Name, " ", "")
+		}
+	}
+	if len(w.Stores) == 0 {
+		w.Stores = []string{chart.StoreIOS, chart.StorePlay}
+	}
+	if len(w.Categories) == 0 {
+		w.Categories = []string{"finance"}
+	}
+	if len(w.Lists) == 0 {
+		w.Lists = []string{chart.ListFree}
+	}
+	if len(w.Devices) == 0 {
+		w.Devices = []string{chart.DeviceIPhone}
+	}
+	for i := range w.Alerts {
+		if w.Alerts[i].Cooldown == 0 {
+			w.Alerts[i].Cooldown = DefaultAlertCooldown
+		}
+	}
+	for i := range w.Notify.Webhooks {
+		if w.Notify.Webhooks[i].Format == "" {
+			w.Notify.Webhooks[i].Format = WebhookJSON
+		}
+	}
+	if e := w.Notify.Email; e != nil {
+		if e.Port == 0 {
+			e.Port = 587
+		}
+		if e.TLS == "" {
+			e.TLS = EmailStartTLS
+		}
+	}
+	for i := range w.Jobs {
+		if w.Jobs[i].Command == "" {
+			w.Jobs[i].Command = JobTrack
+		}
+	}
+}
+
+// normalize spells countries as ISO codes and categories as catalogue keys,
+// so "united-kingdom", "uk" and "GB" all end up as "GB".
+func (w *Watchlist) normalize() {
+	for i, c := range w.Countries {
+		w.Countries[i] = catalog.CountryCode(c)
+	}
+	for i, c := range w.Categories {
+		w.Categories[i] = catalog.CategoryKey(c)
+	}
+}
+
+// Validate reports the first problem with the watchlist.
+func (w *Watchlist) Validate() error {
+	if len(w.Apps) == 0 {
+		return errors.New("no apps declared")
+	}
+	if len(w.Countries) == 0 {
+		return errors.New("no countries declared")
+	}
+
+	keys := make(map[string]bool)
+	for i, app := range w.Apps {
+		if app.Name == "" {
+			return fmt.Errorf("app %d has no name", i+1)
+		}
+		if app.IOSID == "" && app.PlayPackage == "" && len(app.Aliases) == 0 {
+			return fmt.Errorf("app %q needs an ios_id, play_package or aliases", app.Name)
+		}
+		if keys[app.Key] {
+			return fmt.Errorf("app key %q is used twice", app.Key)
+		}
+		keys[app.Key] = true
+	}
+
+	jobs := make(map[string]bool)
+	for i, job := range w.Jobs {
+		switch {
+		case job.Name == "":
+			return fmt.Errorf("job %d has no name", i+1)
+		case jobs[job.Name]:
+			return fmt.Errorf("job name %q is used twice", job.Name)
+		case (job.Cron == "") == (job.Every == 0):
+			return fmt.Errorf("job %q needs either cron or every", job.Name)
+		case job.Every < 0 || job.Jitter < 0:
+			return fmt.Errorf("job %q has a negative duration", job.Name)
+		case job.Command != JobTrack && job.Command != JobDigest:
+			return fmt.Errorf("job %q: unknown command %q, want %s or %s", job.Name, job.Command, JobTrack, JobDigest)
+		case job.Command == JobTrack && len(w.JobRequests(job)) == 0:
+			return fmt.Errorf("job %q covers no charts", job.Name)
+		case job.Command == JobDigest && w.Notify.Email == nil:
+			return fmt.Errorf("job %q needs notify.email", job.Name)
+		}
+		jobs[job.Name] = true
+	}
+
+	if err := w.validateAlerts(keys); err != nil {
+		return err
+	}
+	if err := w.Notify.validate(); err != nil {
+		return err
+	}
+
+	// Every combination must be one the catalogue supports, e.g. a category
+	// that only the App Store has can't be paired with play
+	for _, req := range w.Requests() {
+		if _, err := catalog.Normalize(req); err != nil {
+			return fmt.Errorf("chart %s: %w", req, err)
+		}
+	}
+	return nil
+}
+
+// validateAlerts checks the alert rules against the declared app keys.
+func (w *Watchlist) validateAlerts(keys map[string]bool) error {
+	names := make(map[string]bool)
+	for i, rule := range w.Alerts {
+		switch {
+		case rule.Name == "":
+			return fmt.Errorf("alert %d has no name", i+1)
+		case names[rule.Name]:
+			return fmt.Errorf("alert name %q is used twice", rule.Name)
+		case !slices.Contains(alertKinds, rule.Kind):
+			return fmt.Errorf("alert %q: unknown kind %q, want one of %s", rule.Name, rule.Kind, strings.Join(alertKinds, ", "))
+		case rule.Kind == AlertMoved && rule.Places < 1:
+			return fmt.Errorf("alert %q needs places", rule.Name)
+		case rule.Kind == AlertTop && rule.Top < 1:
+			return fmt.Errorf("alert %q needs top", rule.Name)
+		case rule.Kind == AlertOvertook && !keys[rule.Competitor]:
+			return fmt.Errorf("alert %q: competitor %q isn't an app key", rule.Name, rule.Competitor)
+		case rule.Cooldown < 0:
+			return fmt.Errorf("alert %q has a negative cooldown", rule.Name)
+		}
+		for _, key := range rule.Apps {
+			if !keys[key] {
+				return fmt.Errorf("alert %q: %q isn't an app key", rule.Name, key)
+			}
+		}
+		names[rule.Name] = true
+	}
+	return nil
+}
+
+func (n Notify) validate() error {
+	names := make(map[string]bool)
+	for i, h := range n.Webhooks {
+		switch {
+		case h.Name == "":
+			return fmt.Errorf("webhook %d has no name", i+1)
+		case names[h.Name]:
+			return fmt.Errorf("webhook name %q is used twice", h.Name)
+		case !strings.HasPrefix(h.URL, "http://") && !strings.HasPrefix(h.URL, "https://"):
+			return fmt.Errorf("webhook %q needs an http or https url", h.Name)
+		case !slices.Contains(webhookFormats, h.Format):
+			return fmt.Errorf("webhook %q: unknown format %q, want one of %s", h.Name, h.Format, strings.Join(webhookFormats, ", "))
+		case h.SecretEnv != "" && h.Format != WebhookJSON:
+			return fmt.Errorf("webhook %q: only json webhooks are signed", h.Name)
+		}
+		for _, event := range h.Events {
+			if !slices.Contains(events, event) {
+				return fmt.Errorf("webhook %q: unknown event %q, want one of %s", h.Name, event, strings.Join(events, ", "))
+			}
+		}
+		names[h.Name] = true
+	}
+
+	if e := n.Email; e != nil {
+		switch {
+		case e.Host == "":
+			return errors.New("email needs a host")
+		case e.TLS != EmailStartTLS && e.TLS != EmailNoTLS:
+			return fmt.Errorf("email: unknown tls %q, want %s or %s", e.TLS, EmailStartTLS, EmailNoTLS)
+		case e.From == "" || len(e.To) == 0:
+			return errors.New("email needs from and to addresses")
+		case e.Username != "" && e.PasswordEnv == "":
+			return errors.New("email needs password_env with a username")
+		}
+	}
+	return nil
+}
+
+// Requests returns every chart the watchlist covers, grouped by store then
+// device and country. Devices only split App Store charts.
+func (w *Watchlist) Requests() []chart.Request {
+	var reqs []chart.Request
+	for _, store := range w.Stores {
+		devices := []string{""}
+		if store == chart.StoreIOS {
+			devices = w.Devices
+		}
+		for _, device := range devices {
+			for _, country := range w.Countries {
+				for _, category := range w.Categories {
+					for _, list := range w.Lists {
+						reqs = append(reqs, chart.Request{Store: store, Device: device, Country: country, Category: category, List: list})
+					}
+				}
+			}
+		}
+	}
+	return reqs
+}
+
+// JobRequests returns the watchlist charts job covers.
+func (w *Watchlist) JobRequests(job Job) []chart.Request {
+	var reqs []chart.Request
+	for _, req := range w.Requests() {
+		if len(job.Stores) == 0 || slices.Contains(job.Stores, req.Store) {
+			reqs = append(reqs, req)
+		}
+	}
+	return reqs
+}